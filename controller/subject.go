@@ -1,12 +1,32 @@
 package controller
 
 import (
+	"strconv"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/apperr"
+	"github.com/RZXBxie/web_server/framework/contract"
 	"github.com/RZXBxie/web_server/framework/gin"
 	"github.com/RZXBxie/web_server/provider/demo"
 )
 
+// SubjectController 的依赖在注册路由时由服务容器注入
+type SubjectController struct {
+	Demo  demo.Service   `inject:""`
+	Log   contract.Log   `inject:""`
+	Trace contract.Trace `inject:""`
+}
+
+func (s *SubjectController) List(c *gin.Context) {
+	_, span := s.Trace.Start(c, "demo.GetFoo", contract.SpanKindInternal)
+	foo := s.Demo.GetFoo()
+	span.End()
+	s.Log.Debug(c, "list subjects", map[string]interface{}{"foo": foo.Name})
+	c.ISetOkStatus().IJson(foo)
+}
+
 func SubjectListController(c *gin.Context) {
-	demoService := c.MustMake(demo.Key).(demo.Service)
+	demoService := framework.MustMakeAs[demo.Service](c, demo.Key)
 	c.ISetOkStatus().IJson(demoService.GetFoo())
 }
 
@@ -21,6 +41,11 @@ func SubjectUpdateController(c *gin.Context) {
 }
 
 func SubjectGetController(c *gin.Context) {
+	id, err := strconv.Atoi(c.Param("id"))
+	if err != nil || id <= 0 {
+		_ = c.Error(apperr.ErrBadRequest.WithMessage("subject id must be a positive integer").Wrap(err))
+		return
+	}
 	c.ISetOkStatus().IJson("ok, SubjectGetController")
 
 }
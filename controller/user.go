@@ -8,6 +8,11 @@ import (
 
 func UserLoginController(c *gin.Context) {
 	foo, _ := c.DefaultQueryString("foo", "def")
-	time.Sleep(10 * time.Second)
+	// 模拟耗时的操作，超时之后请求的context被取消，不再继续等待
+	select {
+	case <-time.After(10 * time.Second):
+	case <-c.Request.Context().Done():
+		return
+	}
 	c.ISetOkStatus().IJson("ok, UserLoginController" + foo)
 }
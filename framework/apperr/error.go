@@ -0,0 +1,95 @@
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+)
+
+// Error 是应用错误，描述返回给用户的错误码、状态码和信息，以及不返回给用户的内部原因
+// 在controller中通过 c.Error(err) 记录之后，由 middleware.ErrorHandler 统一输出和记录日志
+type Error struct {
+	// Code 业务错误码，例如 subject_not_found，客户端根据错误码而不是信息判断错误
+	Code string
+	// Status http状态码
+	Status int
+	// Message 返回给用户的信息
+	Message string
+	// Details 返回给用户的附加信息，例如校验失败的字段
+	Details map[string]interface{}
+	// Cause 内部原因，只记录在日志中，不返回给用户
+	Cause error
+}
+
+// 常用的应用错误，可以通过 Wrap、WithMessage、WithDetails 派生，派生的错误和原来的错误满足errors.Is
+var (
+	ErrBadRequest      = New(http.StatusBadRequest, "bad_request", "bad request")
+	ErrUnauthorized    = New(http.StatusUnauthorized, "unauthorized", "unauthorized")
+	ErrForbidden       = New(http.StatusForbidden, "forbidden", "forbidden")
+	ErrNotFound        = New(http.StatusNotFound, "not_found", "not found")
+	ErrConflict        = New(http.StatusConflict, "conflict", "conflict")
+	ErrTooManyRequests = New(http.StatusTooManyRequests, "too_many_requests", "too many requests")
+	ErrInternal        = New(http.StatusInternalServerError, "internal_error", "internal server error")
+	ErrUnavailable     = New(http.StatusServiceUnavailable, "unavailable", "service unavailable")
+	ErrTimeout         = New(http.StatusGatewayTimeout, "timeout", "gateway timeout")
+)
+
+// New 创建应用错误
+func New(status int, code, message string) *Error {
+	return &Error{Code: code, Status: status, Message: message}
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Cause)
+	}
+	return e.Code + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Cause
+}
+
+// Is 错误码和状态码相同的应用错误被认为是同一种错误
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code && t.Status == e.Status
+}
+
+// Wrap 返回以cause为内部原因的副本
+func (e *Error) Wrap(cause error) *Error {
+	copied := e.clone()
+	copied.Cause = cause
+	return copied
+}
+
+// WithMessage 返回使用新的用户信息的副本
+func (e *Error) WithMessage(format string, args ...interface{}) *Error {
+	copied := e.clone()
+	copied.Message = fmt.Sprintf(format, args...)
+	return copied
+}
+
+// WithDetails 返回合并了details的副本，同名的附加信息以details为准
+func (e *Error) WithDetails(details map[string]interface{}) *Error {
+	copied := e.clone()
+	copied.Details = make(map[string]interface{}, len(e.Details)+len(details))
+	maps.Copy(copied.Details, e.Details)
+	maps.Copy(copied.Details, details)
+	return copied
+}
+
+func (e *Error) clone() *Error {
+	copied := *e
+	return &copied
+}
+
+// From 获取err链中的应用错误
+func From(err error) (*Error, bool) {
+	var e *Error
+	if errors.As(err, &e) {
+		return e, true
+	}
+	return nil, false
+}
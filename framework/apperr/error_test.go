@@ -0,0 +1,36 @@
+package apperr
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorDerive(t *testing.T) {
+	cause := errors.New("record not found")
+	err := ErrNotFound.Wrap(cause).WithMessage("subject %d not found", 1).WithDetails(map[string]interface{}{"id": 1})
+
+	assert.Equal(t, http.StatusNotFound, err.Status)
+	assert.Equal(t, "subject 1 not found", err.Message)
+	assert.Equal(t, map[string]interface{}{"id": 1}, err.Details)
+	assert.Equal(t, "not_found: subject 1 not found: record not found", err.Error())
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.ErrorIs(t, err, cause)
+	assert.NotErrorIs(t, err, ErrBadRequest)
+
+	// 派生不会修改原来的错误
+	assert.Equal(t, "not found", ErrNotFound.Message)
+	assert.Nil(t, ErrNotFound.Cause)
+	assert.Nil(t, ErrNotFound.Details)
+}
+
+func TestFrom(t *testing.T) {
+	appErr, ok := From(errors.Join(errors.New("other"), ErrConflict))
+	assert.True(t, ok)
+	assert.Equal(t, "conflict", appErr.Code)
+
+	_, ok = From(errors.New("other"))
+	assert.False(t, ok)
+}
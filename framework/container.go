@@ -1,19 +1,27 @@
 package framework
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 )
 
 // Container 服务容器，提供绑定服务和获取服务的功能
 type Container interface {
-	// Bind 绑定一个服务提供者，如果关键字凭证已经存在，会进行替换操作，不返回error
+	// Bind 绑定一个服务提供者，如果关键字凭证已经存在，会进行替换操作，具名实现见 ServiceVariant
+	// 如果服务提供者声明的依赖构成了循环，或者声明的契约不是接口，则返回error并放弃这次绑定
+	// 非延迟实例化的服务会在它的依赖全部绑定后，按照依赖顺序进行实例化
 	Bind(provider ServiceProvider) error
 
 	// IsBind 关键字凭证是否已经绑定服务提供者
 	IsBind(key string) bool
 
-	// Make 根据关键字凭证获取一个服务的实例
+	// Make 根据关键字凭证获取一个服务的实例，实例的生命周期由服务提供者决定，默认是单例
 	Make(key string) (interface{}, error)
 
 	// MustMake 根据关键字凭证获取一个服务的实例，如果实例不存在，则会panic
@@ -24,6 +32,32 @@ type Container interface {
 	// 它是根据服务提供者注册时的启动函数和传递的 params参数实例化出来的
 	// 这个函数在需要为不同参数启动不同实例的时候非常有用
 	MakeNew(key string, params ...interface{}) (interface{}, error)
+
+	// TaggedKeys 按照绑定的顺序返回带有标签tag的所有服务的关键字凭证
+	TaggedKeys(tag string) []string
+
+	// MakeTagged 按照绑定的顺序获取带有标签tag的所有服务的实例
+	MakeTagged(tag string) ([]interface{}, error)
+
+	// Decorate 为关键字凭证key注册一个装饰器，这个服务之后的每次实例化都会经过装饰器，详见 Decorator
+	Decorate(key string, decorator Decorator) error
+
+	// NewChild 创建一个子容器，子容器继承容器中所有的绑定，在子容器中Bind可以覆盖指定的服务而不影响容器本身
+	NewChild() Container
+
+	// NewScope 创建一个作用域，作用域中Scoped生命周期的服务只实例化一次，其他服务的获取方式和容器相同
+	// 作用域用完之后需要调用它的Shutdown停止作用域中的服务
+	NewScope() Container
+
+	// Bindings 返回容器中所有绑定的服务的信息，按照关键字凭证排序，用于调试和排查问题
+	Bindings() []BindingInfo
+
+	// Warmup 在启动服务器之前并发地实例化指定的单例，timeout是每个服务的超时时间，返回每个服务的耗时
+	Warmup(ctx context.Context, timeout time.Duration, keys ...string) ([]WarmupResult, error)
+
+	// Shutdown 按照依赖的相反顺序停止容器管理的实例，ctx 决定了停止的截止时间
+	// MakeNew 以及Transient生命周期的实例不由容器管理，需要调用方自己停止
+	Shutdown(ctx context.Context) error
 }
 
 // MyContainer 是服务容器的具体实现
@@ -37,52 +71,259 @@ type MyContainer struct {
 	// instances 存储实例化后的服务实例，key为服务名，value为服务实例
 	instances map[string]interface{}
 
+	// pending 存储还在等待依赖绑定的非延迟实例化服务的服务名
+	pending []string
+
+	// calls 存储正在进行中的实例化，保证同一个服务并发make时只实例化一次
+	calls map[string]*makeCall
+
+	// order 按照实例化完成的顺序存储单例，被依赖的服务总是在前面
+	// 重新绑定时被替换的单例仍然保留在这里，依赖它的服务可能还在使用它，直到Shutdown时才停止
+	order []orderedInstance
+
+	// keys 按照绑定的顺序存储所有的关键字凭证，具名实现使用 VariantKey
+	keys []string
+
+	// variants 存储每个关键字凭证下按照绑定顺序排列的具名实现
+	variants map[string][]string
+
+	// decorators 存储每个关键字凭证上按照注册顺序排列的装饰器
+	decorators map[string][]Decorator
+
+	// parent 是子容器的父容器，子容器中没有绑定的服务从父容器中获取
+	parent *MyContainer
+
+	// stats 存储每个服务的实例化统计，包括所有生命周期的实例化
+	stats map[string]makeStat
+
+	// ctx 传递给服务的Start方法，在容器Shutdown时取消
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// shutdown 表示容器已经关闭
+	shutdown bool
+
 	// lock 用于锁住对容器的变更操作
 	lock sync.RWMutex
 }
 
+// NewChild 子容器中没有绑定的服务从父容器中获取，父容器的单例在父子容器之间共享
+// 子容器中绑定的服务的依赖优先在子容器中查找，所以覆盖一个服务之后，子容器中依赖它的服务也会使用覆盖后的实现
+// 子容器的Shutdown只停止子容器自己的实例，需要在父容器之前调用
+func (c *MyContainer) NewChild() Container {
+	child := newContainer(c.ctx)
+	child.parent = c
+	return child
+}
+
+// orderedInstance 是一个需要在Shutdown时停止的实例
+type orderedInstance struct {
+	key      string
+	instance interface{}
+}
+
+// makeCall 代表一次正在进行中的服务实例化，同一个服务的其他调用者等待它的结果
+type makeCall struct {
+	done     chan struct{}
+	instance interface{}
+	err      error
+}
+
 func NewContainer() *MyContainer {
+	return newContainer(context.Background())
+}
+
+// newContainer 创建一个容器，parent 被取消时容器传递给服务Start的ctx也会被取消
+func newContainer(parent context.Context) *MyContainer {
+	ctx, cancel := context.WithCancel(parent)
 	return &MyContainer{
-		providers: make(map[string]ServiceProvider),
-		instances: make(map[string]interface{}),
-		lock:      sync.RWMutex{},
+		providers:  make(map[string]ServiceProvider),
+		instances:  make(map[string]interface{}),
+		calls:      make(map[string]*makeCall),
+		variants:   make(map[string][]string),
+		decorators: make(map[string][]Decorator),
+		stats:      make(map[string]makeStat),
+		ctx:        ctx,
+		cancel:     cancel,
+		lock:       sync.RWMutex{},
 	}
 }
 
 func (c *MyContainer) Bind(provider ServiceProvider) error {
+	key := providerKey(provider)
+	if contract := providerContract(provider); contract != nil && contract.Kind() != reflect.Interface {
+		return fmt.Errorf("contract %s: %v is not an interface", key, contract)
+	}
+
 	c.lock.Lock()
-	defer c.lock.Unlock()
-	key := provider.Name()
+	old, replaced := c.providers[key]
 	c.providers[key] = provider
+	if !replaced {
+		c.keys = append(c.keys, key)
+		if variant := providerVariant(provider); variant != "" {
+			c.variants[provider.Name()] = append(c.variants[provider.Name()], key)
+		}
+	}
+	if cycle := c.findCycle(key); cycle != nil {
+		// 依赖构成循环，恢复绑定之前的状态
+		if replaced {
+			c.providers[key] = old
+		} else {
+			delete(c.providers, key)
+			c.keys = slices.DeleteFunc(c.keys, func(k string) bool { return k == key })
+			c.variants[provider.Name()] = slices.DeleteFunc(c.variants[provider.Name()], func(k string) bool { return k == key })
+		}
+		c.lock.Unlock()
+		return fmt.Errorf("contract %s has dependency cycle: %s", key, strings.Join(cycle, " -> "))
+	}
+	// 替换了服务提供者，之前的实例以及进行中的实例化结果也随之失效，之前的实例在Shutdown时停止
+	delete(c.instances, key)
+	delete(c.calls, key)
+	delete(c.stats, key)
+	c.removePending(key)
+	// 只有单例才需要在绑定的时候实例化
+	if !provider.IsDefer() && providerLifetime(provider) == Singleton {
+		c.pending = append(c.pending, key)
+	}
+	ready := c.takeReady()
+	c.lock.Unlock()
 
-	if !provider.IsDefer() {
-		if err := provider.Boot(c); err != nil {
+	// 依赖已经全部绑定的非延迟服务，按照依赖顺序实例化
+	for _, k := range ready {
+		if _, err := c.make(k, nil, false); err != nil {
 			return err
 		}
-		params := provider.Params(c)
-		method := provider.Register(c)
-		instance, err := method(params...)
-		if err != nil {
-			return err
+	}
+	return nil
+}
+
+// findCycle 从start开始沿着依赖查找是否存在回到start的循环，存在则返回循环路径，调用方需要持有锁
+func (c *MyContainer) findCycle(start string) []string {
+	visited := make(map[string]bool)
+	var path []string
+	var visit func(key string) []string
+	visit = func(key string) []string {
+		path = append(path, key)
+		defer func() { path = path[:len(path)-1] }()
+
+		sp, ok := c.lookup(key)
+		if !ok {
+			return nil
 		}
-		c.instances[key] = instance
+		for _, dep := range providerDepends(sp) {
+			if dep == start {
+				return append(append([]string{}, path...), start)
+			}
+			if visited[dep] {
+				continue
+			}
+			visited[dep] = true
+			if cycle := visit(dep); cycle != nil {
+				return cycle
+			}
+		}
+		return nil
 	}
+	return visit(start)
+}
 
-	return nil
+// findUnbound 查找key的依赖链上第一个没有绑定的服务，返回从key到这个服务的路径，调用方需要持有锁
+func (c *MyContainer) findUnbound(key string) []string {
+	visited := make(map[string]bool)
+	var visit func(path []string) []string
+	visit = func(path []string) []string {
+		key := path[len(path)-1]
+		sp, ok := c.lookup(key)
+		if !ok {
+			return path
+		}
+		for _, dep := range providerDepends(sp) {
+			if visited[dep] {
+				continue
+			}
+			visited[dep] = true
+			if unbound := visit(append(path[:len(path):len(path)], dep)); unbound != nil {
+				return unbound
+			}
+		}
+		return nil
+	}
+	return visit([]string{key})
+}
+
+// removePending 从等待队列中移除key，调用方需要持有锁
+func (c *MyContainer) removePending(key string) {
+	for i, k := range c.pending {
+		if k == key {
+			c.pending = append(c.pending[:i], c.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// takeReady 取出等待队列中依赖已经全部绑定的服务，按照被依赖的服务在前的顺序返回，调用方需要持有锁
+func (c *MyContainer) takeReady() []string {
+	ready := make(map[string]bool)
+	var keys, rest []string
+	for _, key := range c.pending {
+		if c.findUnbound(key) == nil {
+			ready[key] = true
+			keys = append(keys, key)
+		} else {
+			rest = append(rest, key)
+		}
+	}
+	c.pending = rest
+
+	var sorted []string
+	visited := make(map[string]bool)
+	var visit func(key string)
+	visit = func(key string) {
+		if visited[key] {
+			return
+		}
+		visited[key] = true
+		sp, _ := c.lookup(key)
+		for _, dep := range providerDepends(sp) {
+			visit(dep)
+		}
+		if ready[key] {
+			sorted = append(sorted, key)
+		}
+	}
+	for _, key := range keys {
+		visit(key)
+	}
+	return sorted
 }
 
+// IsBind 子容器中没有绑定时会继续查找父容器
 func (c *MyContainer) IsBind(key string) bool {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
-	_, ok := c.providers[key]
+	_, ok := c.lookup(key)
 	return ok
 }
 
+// lookup 在容器以及父容器中查找服务提供者，子容器中的绑定优先，调用方需要持有锁
+// key 没有直接绑定但是有具名实现时，返回默认的具名实现
+func (c *MyContainer) lookup(key string) (ServiceProvider, bool) {
+	if own, ok := c.ownKey(key); ok {
+		return c.providers[own], true
+	}
+	if c.parent == nil {
+		return nil, false
+	}
+	c.parent.lock.RLock()
+	defer c.parent.lock.RUnlock()
+	return c.parent.lookup(key)
+}
+
 // findServiceProvider 查找是否已经注册了这个服务提供者，如果没有注册，则返回nil
 func (c *MyContainer) findServiceProvider(key string) ServiceProvider {
 	c.lock.RLock()
 	defer c.lock.RUnlock()
-	if sp, ok := c.providers[key]; ok {
+	if sp, ok := c.lookup(key); ok {
 		return sp
 	}
 	return nil
@@ -101,50 +342,194 @@ func (c *MyContainer) MustMake(key string) interface{} {
 }
 
 func (c *MyContainer) MakeNew(key string, params ...interface{}) (interface{}, error) {
-	return c.make(key, params, false)
+	return c.make(key, params, true)
 }
 
-// make 真正的实例化一个服务
-func (c *MyContainer) make(key string, params []interface{}, forceNew bool) (interface{}, error) {
+// cached 返回已经实例化的单例，子容器中没有绑定时查找父容器，和make的查找规则一致
+func (c *MyContainer) cached(key string) (interface{}, bool) {
 	c.lock.RLock()
-	defer c.lock.RUnlock()
+	key, own := c.ownKey(key)
+	ins, ok := c.instances[key]
+	c.lock.RUnlock()
+	if ok || own || c.parent == nil {
+		return ins, ok
+	}
+	return c.parent.cached(key)
+}
+
+// CachedInstance 返回容器或者作用域中已经实例化的服务，不会实例化服务，也不会创建作用域
+// 用于请求处理这样的热路径，大部分服务在第一次请求之后都已经实例化了
+func CachedInstance(c Container, key string) (interface{}, bool) {
+	switch c := c.(type) {
+	case *MyContainer:
+		return c.cached(key)
+	case *Scope:
+		return c.cached(key)
+	}
+	return nil, false
+}
 
+// findProvider 查找可以实例化的服务提供者，调用方需要持有锁
+// 容器已经关闭、服务提供者没有注册、依赖链上存在没有绑定的服务时返回error
+func (c *MyContainer) findProvider(key string) (ServiceProvider, error) {
+	if c.shutdown {
+		return nil, ErrContainerShutdown
+	}
 	// 查询是否已经注册了这个服务提供者，如果没有注册，则报错
-	sp := c.findServiceProvider(key)
-	if sp == nil {
+	sp, ok := c.lookup(key)
+	if !ok {
 		return nil, errors.New("contract " + key + " not found.")
 	}
-	if forceNew {
-		return c.newInstance(sp, params)
+	// 依赖链上存在没有绑定的服务，拒绝实例化
+	if unbound := c.findUnbound(key); unbound != nil {
+		return nil, fmt.Errorf("contract %s depends on unbound contract %s: %s",
+			key, unbound[len(unbound)-1], strings.Join(unbound, " -> "))
 	}
+	return sp, nil
+}
 
-	// 不需要强制重新实例化，如果容器中已经实例化了，那么就直接使用容器中的实例
-	if ins, ok := c.instances[key]; ok {
+// make 真正的实例化一个服务
+// 单例的实例化在同一个key上只会进行一次，并发的调用者等待这次实例化的结果，实例化失败的结果不会被缓存
+// 实例化过程中不持有容器的锁，所以服务提供者的Boot、Register中可以继续调用容器的方法
+func (c *MyContainer) make(key string, params []interface{}, forceNew bool) (interface{}, error) {
+	// 已经实例化的单例直接返回，这是最常见的情况，只需要读锁
+	c.lock.RLock()
+	key, own := c.ownKey(key)
+	ins, ok := c.instances[key]
+	c.lock.RUnlock()
+	if ok && !forceNew {
 		return ins, nil
 	}
+	// 子容器中没有绑定这个服务，交给父容器实例化，父容器的单例在所有子容器中共享
+	if !own && c.parent != nil {
+		return c.parent.make(key, params, forceNew)
+	}
 
-	// 如果容器中不存在实例化服务，则进行实例化
-	instance, err := c.newInstance(sp, nil)
+	c.lock.Lock()
+	sp, err := c.findProvider(key)
 	if err != nil {
+		c.lock.Unlock()
 		return nil, err
 	}
-	c.instances[key] = instance
-	return instance, nil
+	switch lifetime := providerLifetime(sp); {
+	case forceNew || lifetime == Transient:
+		// 新的实例不由容器管理，不需要缓存和启动
+		c.lock.Unlock()
+		if err := makeDepends(c, sp); err != nil {
+			return nil, err
+		}
+		return c.newInstance(c, sp, params)
+	case lifetime == Scoped:
+		c.lock.Unlock()
+		return nil, fmt.Errorf("contract %s is scoped and can only be made in a scope", key)
+	}
+	// 在获取写锁的过程中可能已经有其他调用者完成了实例化
+	if ins, ok := c.instances[key]; ok {
+		c.lock.Unlock()
+		return ins, nil
+	}
+	// 已经有调用者在实例化这个服务，等待它的结果
+	if call, ok := c.calls[key]; ok {
+		c.lock.Unlock()
+		<-call.done
+		return call.instance, call.err
+	}
+	call := &makeCall{done: make(chan struct{})}
+	c.calls[key] = call
+	c.lock.Unlock()
+
+	defer func() {
+		// Boot或Register发生panic时也要唤醒等待者，panic继续向上抛出
+		if p := recover(); p != nil {
+			call.err = fmt.Errorf("contract %s panic during make: %v", key, p)
+			c.finishCall(key, call)
+			panic(p)
+		}
+		c.finishCall(key, call)
+	}()
+
+	// 先实例化依赖的服务，保证依赖先于当前服务启动
+	if call.err = makeDepends(c, sp); call.err != nil {
+		return nil, call.err
+	}
+	if call.instance, call.err = c.newInstance(c, sp, nil); call.err != nil {
+		return nil, call.err
+	}
+	// 单例由容器管理生命周期，实例化之后启动它
+	if call.err = startInstance(c.ctx, key, call.instance); call.err != nil {
+		call.instance = nil
+		return nil, call.err
+	}
+	return call.instance, nil
 }
 
-// newInstance 实例化一个服务
-func (c *MyContainer) newInstance(sp ServiceProvider, params []interface{}) (interface{}, error) {
-	if err := sp.Boot(c); err != nil {
+// finishCall 结束一次实例化，成功的实例会被缓存为单例，并唤醒所有等待者
+func (c *MyContainer) finishCall(key string, call *makeCall) {
+	var orphan interface{}
+	c.lock.Lock()
+	current := c.calls[key] == call
+	if current {
+		delete(c.calls, key)
+	}
+	switch {
+	case call.err != nil:
+	case c.shutdown:
+		// 实例化过程中容器关闭了，这个实例不会再被Shutdown停止，由这里停止
+		orphan, call.instance, call.err = call.instance, nil, ErrContainerShutdown
+	case current:
+		c.instances[key] = call.instance
+		c.order = append(c.order, orderedInstance{key: key, instance: call.instance})
+	default:
+		// 实例化过程中服务提供者被重新绑定了，这时结果已经失效，不能缓存，但是已经启动，仍然在Shutdown时停止
+		c.order = append(c.order, orderedInstance{key: key, instance: call.instance})
+	}
+	c.lock.Unlock()
+	close(call.done)
+	if orphan != nil {
+		_ = stopInstance(context.Background(), key, orphan)
+	}
+}
+
+// makeDepends 在容器c中实例化服务提供者依赖的所有服务
+func makeDepends(c Container, sp ServiceProvider) error {
+	for _, dep := range providerDepends(sp) {
+		if _, err := c.Make(dep); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newInstance 在容器in中实例化一个服务，in会作为参数传递给服务提供者，in可以是根容器或者作用域
+// 实例经过装饰器包装之后再检查契约，实例化成功之后记录实例化的耗时
+func (c *MyContainer) newInstance(in Container, sp ServiceProvider, params []interface{}) (interface{}, error) {
+	start := time.Now()
+	if err := sp.Boot(in); err != nil {
 		return nil, err
 	}
 	if params == nil {
-		params = sp.Params(c)
+		params = sp.Params(in)
 	}
 
-	method := sp.Register(c)
+	method := sp.Register(in)
 	instance, err := method(params...)
 	if err != nil {
 		return nil, errors.New(err.Error())
 	}
+	if instance, err = c.decorate(in, sp, instance); err != nil {
+		return nil, err
+	}
+	if err := checkContract(sp, instance); err != nil {
+		return nil, err
+	}
+
+	c.lock.Lock()
+	key := providerKey(sp)
+	stat := c.stats[key]
+	stat.count++
+	stat.at = start
+	stat.duration = time.Since(start)
+	c.stats[key] = stat
+	c.lock.Unlock()
 	return instance, nil
 }
@@ -0,0 +1,337 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testProvider 是测试中使用的服务提供者，实例就是它自己的名字
+type testProvider struct {
+	name     string
+	deferred bool
+	depends  []string
+	bootErr  error
+
+	// booted 记录服务的启动顺序，多个testProvider可以共享同一个记录
+	booted *[]string
+	mu     *sync.Mutex
+}
+
+func newTestProvider(name string, isDefer bool, depends ...string) *testProvider {
+	return &testProvider{name: name, deferred: isDefer, depends: depends, booted: &[]string{}, mu: &sync.Mutex{}}
+}
+
+func (p *testProvider) Name() string                   { return p.name }
+func (p *testProvider) IsDefer() bool                  { return p.deferred }
+func (p *testProvider) Depends() []string              { return p.depends }
+func (p *testProvider) Params(Container) []interface{} { return nil }
+
+func (p *testProvider) Boot(Container) error {
+	if p.bootErr != nil {
+		return p.bootErr
+	}
+	p.mu.Lock()
+	*p.booted = append(*p.booted, p.name)
+	p.mu.Unlock()
+	return nil
+}
+
+func (p *testProvider) Register(Container) NewInstance {
+	return func(...interface{}) (interface{}, error) {
+		return p.name, nil
+	}
+}
+
+// share 让多个testProvider共享同一个启动顺序记录
+func share(providers ...*testProvider) *[]string {
+	booted, mu := &[]string{}, &sync.Mutex{}
+	for _, p := range providers {
+		p.booted, p.mu = booted, mu
+	}
+	return booted
+}
+
+func TestContainerBootsInDependencyOrder(t *testing.T) {
+	c := NewContainer()
+	app := newTestProvider("app", false, "db", "cache")
+	db := newTestProvider("db", false, "config")
+	cache := newTestProvider("cache", true, "config")
+	config := newTestProvider("config", true)
+	booted := share(app, db, cache, config)
+
+	// app和db的依赖还没有绑定，不会被实例化
+	require.NoError(t, c.Bind(app))
+	require.NoError(t, c.Bind(db))
+	require.NoError(t, c.Bind(cache))
+	assert.Empty(t, *booted)
+
+	require.NoError(t, c.Bind(config))
+	assert.Equal(t, []string{"config", "db", "cache", "app"}, *booted)
+}
+
+func TestContainerBindDetectsCycle(t *testing.T) {
+	c := NewContainer()
+	require.NoError(t, c.Bind(newTestProvider("a", true, "b")))
+	require.NoError(t, c.Bind(newTestProvider("b", true, "c")))
+
+	err := c.Bind(newTestProvider("c", true, "a"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "c -> a -> b -> c")
+	assert.False(t, c.IsBind("c"))
+
+	err = c.Bind(newTestProvider("self", true, "self"))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "self -> self")
+}
+
+func TestContainerBindKeepsOldProviderOnCycle(t *testing.T) {
+	c := NewContainer()
+	require.NoError(t, c.Bind(newTestProvider("a", true, "b")))
+	require.NoError(t, c.Bind(newTestProvider("b", true)))
+
+	require.Error(t, c.Bind(newTestProvider("b", true, "a")))
+	ins, err := c.Make("a")
+	require.NoError(t, err)
+	assert.Equal(t, "a", ins)
+}
+
+func TestContainerMakeRefusesUnboundDependency(t *testing.T) {
+	c := NewContainer()
+	require.NoError(t, c.Bind(newTestProvider("a", true, "b")))
+	require.NoError(t, c.Bind(newTestProvider("b", true, "c")))
+
+	_, err := c.Make("a")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "unbound contract c")
+	assert.Contains(t, err.Error(), "a -> b -> c")
+
+	require.NoError(t, c.Bind(newTestProvider("c", true)))
+	ins, err := c.Make("a")
+	require.NoError(t, err)
+	assert.Equal(t, "a", ins)
+}
+
+func TestContainerMakeBootError(t *testing.T) {
+	c := NewContainer()
+	p := newTestProvider("a", true)
+	p.bootErr = errors.New("boot failed")
+	require.NoError(t, c.Bind(p))
+
+	_, err := c.Make("a")
+	assert.EqualError(t, err, "boot failed")
+	_, err = c.Make("missing")
+	assert.EqualError(t, err, "contract missing not found.")
+}
+
+// countingProvider 记录实例化的次数，前failures次实例化会失败
+type countingProvider struct {
+	name     string
+	failures int32
+	created  atomic.Int32
+	attempts atomic.Int32
+}
+
+func (p *countingProvider) Name() string                   { return p.name }
+func (p *countingProvider) IsDefer() bool                  { return true }
+func (p *countingProvider) Params(Container) []interface{} { return nil }
+func (p *countingProvider) Boot(Container) error           { return nil }
+
+func (p *countingProvider) Register(Container) NewInstance {
+	return func(...interface{}) (interface{}, error) {
+		// 放大并发窗口，让更多的调用者同时进入实例化
+		time.Sleep(time.Millisecond)
+		if p.attempts.Add(1) <= p.failures {
+			return nil, errors.New("register failed")
+		}
+		return &struct{ n int32 }{p.created.Add(1)}, nil
+	}
+}
+
+func makeConcurrently(c Container, key string, n int) ([]interface{}, []error) {
+	instances := make([]interface{}, n)
+	errs := make([]error, n)
+	start := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			<-start
+			instances[i], errs[i] = c.Make(key)
+		}(i)
+	}
+	close(start)
+	wg.Wait()
+	return instances, errs
+}
+
+func TestContainerConcurrentMakeInstantiatesOnce(t *testing.T) {
+	c := NewContainer()
+	p := &countingProvider{name: "counting"}
+	require.NoError(t, c.Bind(p))
+
+	instances, errs := makeConcurrently(c, "counting", 100)
+	for i := range instances {
+		require.NoError(t, errs[i])
+		assert.Same(t, instances[0], instances[i])
+	}
+	assert.EqualValues(t, 1, p.created.Load())
+}
+
+func TestContainerConcurrentMakeDoesNotCacheFailure(t *testing.T) {
+	c := NewContainer()
+	p := &countingProvider{name: "counting", failures: 1}
+	require.NoError(t, c.Bind(p))
+
+	// 失败的实例化结束之后才加入的调用者会重新实例化并成功，所以只检查失败没有被缓存
+	_, errs := makeConcurrently(c, "counting", 50)
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			assert.EqualError(t, err, "register failed")
+			failed++
+		}
+	}
+	assert.Positive(t, failed)
+
+	ins, err := c.Make("counting")
+	require.NoError(t, err)
+	again, err := c.Make("counting")
+	require.NoError(t, err)
+	assert.Same(t, ins, again)
+	assert.EqualValues(t, 1, p.created.Load())
+}
+
+func TestContainerConcurrentMakeWithDependencies(t *testing.T) {
+	c := NewContainer()
+	base := &countingProvider{name: "base"}
+	require.NoError(t, c.Bind(base))
+	require.NoError(t, c.Bind(newTestProvider("app", true, "base")))
+
+	_, errs := makeConcurrently(c, "app", 100)
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 1, base.created.Load())
+}
+
+func TestContainerConcurrentBindAndMake(t *testing.T) {
+	c := NewContainer()
+	require.NoError(t, c.Bind(&countingProvider{name: "counting"}))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, c.Bind(&countingProvider{name: "counting"}))
+		}()
+		go func() {
+			defer wg.Done()
+			_, err := c.Make("counting")
+			assert.NoError(t, err)
+			assert.True(t, c.IsBind("counting"))
+		}()
+	}
+	wg.Wait()
+}
+
+func TestContainerMakePanicReleasesWaiters(t *testing.T) {
+	c := NewContainer()
+	p := newTestProvider("panic", true)
+	require.NoError(t, c.Bind(&panicProvider{testProvider: p}))
+
+	assert.Panics(t, func() { _, _ = c.Make("panic") })
+	// panic之后没有遗留进行中的实例化，后续的调用不会被阻塞
+	assert.Panics(t, func() { _, _ = c.Make("panic") })
+}
+
+// panicProvider 在Register时发生panic
+type panicProvider struct {
+	*testProvider
+}
+
+func (p *panicProvider) Register(Container) NewInstance {
+	return func(...interface{}) (interface{}, error) {
+		panic("register panic")
+	}
+}
+
+func TestChildContainerOverridesParent(t *testing.T) {
+	parent := NewContainer()
+	bindInstance(t, parent, "store", "mysql")
+	bindInstance(t, parent, "cache", "redis")
+	require.NoError(t, parent.Bind(&dependentProvider{testProvider: newTestProvider("repo", true, "store")}))
+
+	child := parent.NewChild()
+	require.True(t, child.IsBind("store"))
+	require.NoError(t, child.Bind(&instanceProvider{testProvider: newTestProvider("store", true), instance: "fake"}))
+	require.NoError(t, child.Bind(&dependentProvider{testProvider: newTestProvider("repo", true, "store")}))
+
+	store, err := child.Make("store")
+	require.NoError(t, err)
+	assert.Equal(t, "fake", store)
+	store, err = parent.Make("store")
+	require.NoError(t, err)
+	assert.Equal(t, "mysql", store)
+
+	// 子容器中重新绑定的服务使用子容器中覆盖的依赖
+	repo, err := child.Make("repo")
+	require.NoError(t, err)
+	assert.Equal(t, "repo(fake)", repo)
+	repo, err = parent.Make("repo")
+	require.NoError(t, err)
+	assert.Equal(t, "repo(mysql)", repo)
+
+	// 没有覆盖的服务和父容器共享单例
+	cache, err := child.Make("cache")
+	require.NoError(t, err)
+	assert.Equal(t, "redis", cache)
+	assert.Len(t, parent.Bindings(), 3)
+	infos := child.Bindings()
+	require.Len(t, infos, 3)
+	assert.True(t, infos[0].Inherited)
+	assert.Equal(t, "cache", infos[0].Key)
+	assert.True(t, infos[0].Instantiated)
+	assert.False(t, infos[2].Inherited)
+}
+
+func TestChildContainerShutdown(t *testing.T) {
+	parent := NewContainer()
+	events, mu := &[]string{}, &sync.Mutex{}
+	bindInstance(t, parent, "parent", &lifecycleService{name: "parent", events: events, mu: mu})
+	child := parent.NewChild()
+	require.NoError(t, child.Bind(&instanceProvider{testProvider: newTestProvider("child", true, "parent"),
+		instance: &lifecycleService{name: "child", events: events, mu: mu}}))
+
+	_, err := child.Make("child")
+	require.NoError(t, err)
+	require.NoError(t, child.Shutdown(context.Background()))
+	_, err = parent.Make("parent")
+	require.NoError(t, err)
+	require.NoError(t, parent.Shutdown(context.Background()))
+	assert.Equal(t, []string{"start parent", "start child", "stop child", "stop parent"}, *events)
+}
+
+// dependentProvider 的实例包含它依赖的第一个服务的实例
+type dependentProvider struct {
+	*testProvider
+}
+
+func (p *dependentProvider) Register(c Container) NewInstance {
+	return func(...interface{}) (interface{}, error) {
+		dep, err := c.Make(p.depends[0])
+		if err != nil {
+			return nil, err
+		}
+		return fmt.Sprintf("%s(%v)", p.name, dep), nil
+	}
+}
@@ -0,0 +1,119 @@
+package framework
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ServiceContract 是服务提供者可以选择实现的接口，用于声明服务实例满足的契约接口
+// 实现了这个接口的服务提供者，每次实例化之后都会检查实例是否实现了契约接口，不满足时实例化失败
+// 非延迟实例化的服务在Bind的时候就会实例化，所以契约不满足时Bind会直接返回error
+type ServiceContract interface {
+	// Contract 返回契约接口的类型，一般使用 ContractOf 生成，例如 ContractOf[demo.Service]()
+	Contract() reflect.Type
+}
+
+// ContractOf 返回契约接口T的类型
+func ContractOf[T any]() reflect.Type {
+	return reflect.TypeFor[T]()
+}
+
+// ContractError 表示服务实例的类型不满足期望的类型
+type ContractError struct {
+	// Key 服务的关键字凭证
+	Key string
+	// Expected 期望的类型，一般是契约接口
+	Expected reflect.Type
+	// Actual 服务实例实际的类型，实例为nil时为nil
+	Actual reflect.Type
+}
+
+func (e *ContractError) Error() string {
+	actual := "<nil>"
+	if e.Actual != nil {
+		actual = e.Actual.String()
+	}
+	if e.Expected.Kind() == reflect.Interface {
+		return fmt.Sprintf("contract %s: instance of type %s does not implement %s", e.Key, actual, e.Expected)
+	}
+	return fmt.Sprintf("contract %s: instance of type %s is not %s", e.Key, actual, e.Expected)
+}
+
+// Maker 能够根据关键字凭证获取服务实例，Container 和 gin.Context 都实现了这个接口
+type Maker interface {
+	Make(key string) (interface{}, error)
+}
+
+// MakeAs 根据关键字凭证获取服务实例，并转换为类型T
+// 实例不是类型T时返回 *ContractError，其中包含关键字凭证、期望的类型和实例实际的类型
+func MakeAs[T any](m Maker, key string) (T, error) {
+	var zero T
+	instance, err := m.Make(key)
+	if err != nil {
+		return zero, err
+	}
+	service, ok := instance.(T)
+	if !ok {
+		return zero, &ContractError{Key: key, Expected: reflect.TypeFor[T](), Actual: reflect.TypeOf(instance)}
+	}
+	return service, nil
+}
+
+// MustMakeAs 和 MakeAs 相同，获取失败时panic
+func MustMakeAs[T any](m Maker, key string) T {
+	service, err := MakeAs[T](m, key)
+	if err != nil {
+		panic(err)
+	}
+	return service
+}
+
+// providerContract 获取服务提供者声明的契约接口，没有声明时返回nil
+func providerContract(sp ServiceProvider) reflect.Type {
+	if c, ok := sp.(ServiceContract); ok {
+		return c.Contract()
+	}
+	return nil
+}
+
+// checkContract 检查服务实例是否满足服务提供者声明的契约接口
+func checkContract(sp ServiceProvider, instance interface{}) error {
+	contract := providerContract(sp)
+	if contract == nil {
+		return nil
+	}
+	actual := reflect.TypeOf(instance)
+	if actual == nil || !actual.AssignableTo(contract) {
+		return &ContractError{Key: providerKey(sp), Expected: contract, Actual: actual}
+	}
+	return nil
+}
+
+// KeyOf 查找声明了契约接口contract的服务提供者的关键字凭证，子容器中同时查找父容器
+// 如果声明了这个契约的都是同一个关键字凭证下的具名实现，返回这个关键字凭证，获取时使用默认实现
+// 没有服务提供者声明这个契约，或者有多个不同的关键字凭证声明了这个契约时返回error
+func (c *MyContainer) KeyOf(contract reflect.Type) (string, error) {
+	var keys []string
+	names := make(map[string]bool)
+	for _, info := range c.Bindings() {
+		c.lock.RLock()
+		sp, _ := c.lookup(info.Key)
+		c.lock.RUnlock()
+		if providerContract(sp) == contract {
+			keys = append(keys, info.Key)
+			names[sp.Name()] = true
+		}
+	}
+	switch {
+	case len(keys) == 0:
+		return "", fmt.Errorf("no provider declares contract %v", contract)
+	case len(keys) == 1:
+		return keys[0], nil
+	case len(names) == 1:
+		for name := range names {
+			return name, nil
+		}
+	}
+	return "", fmt.Errorf("contract %v is declared by multiple providers: %s", contract, strings.Join(keys, ", "))
+}
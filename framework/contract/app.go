@@ -0,0 +1,39 @@
+package contract
+
+// AppKey 应用环境服务的关键字凭证
+const AppKey = "web:app"
+
+// 应用运行的环境
+const (
+	// EnvDevelopment 开发环境，gin运行在debug模式
+	EnvDevelopment = "development"
+	// EnvTesting 测试环境，gin运行在test模式
+	EnvTesting = "testing"
+	// EnvProduction 生产环境，gin运行在release模式
+	EnvProduction = "production"
+)
+
+// App 应用环境服务，提供应用运行的环境以及各个目录的路径
+type App interface {
+	// Environment 返回应用运行的环境，是 EnvDevelopment、EnvTesting、EnvProduction 之一
+	Environment() string
+	// IsDevelopment 是否运行在开发环境
+	IsDevelopment() bool
+	// IsTesting 是否运行在测试环境
+	IsTesting() bool
+	// IsProduction 是否运行在生产环境
+	IsProduction() bool
+
+	// BaseFolder 应用的根目录
+	BaseFolder() string
+	// ConfigFolder 所有环境共用的配置目录
+	ConfigFolder() string
+	// EnvConfigFolder 当前环境的配置目录，其中的配置覆盖 ConfigFolder 中的配置
+	EnvConfigFolder() string
+	// StorageFolder 存放应用产生的文件的目录
+	StorageFolder() string
+	// LogFolder 存放日志的目录
+	LogFolder() string
+	// RuntimeFolder 存放运行时文件的目录，例如pid文件
+	RuntimeFolder() string
+}
@@ -0,0 +1,53 @@
+package contract
+
+import "time"
+
+// ConfigKey 配置服务的关键字凭证
+const ConfigKey = "web:config"
+
+// Config 配置服务，配置项使用点号分隔的路径获取，例如 app.address
+// 配置项不存在时，各个Get方法返回对应类型的零值
+type Config interface {
+	// IsExist 配置项是否存在
+	IsExist(key string) bool
+
+	// Get 获取配置项的原始值，map和数组类型的值是拷贝，修改它们不会影响配置
+	Get(key string) interface{}
+	// GetBool 获取bool类型的配置项
+	GetBool(key string) bool
+	// GetInt 获取int类型的配置项
+	GetInt(key string) int
+	// GetInt64 获取int64类型的配置项
+	GetInt64(key string) int64
+	// GetFloat64 获取float64类型的配置项
+	GetFloat64(key string) float64
+	// GetString 获取string类型的配置项
+	GetString(key string) string
+	// GetDuration 获取时间间隔类型的配置项，支持 "5s" 这样的字符串以及表示纳秒的数字
+	GetDuration(key string) time.Duration
+	// GetStringSlice 获取字符串数组类型的配置项
+	GetStringSlice(key string) []string
+	// GetStringMap 获取map类型的配置项
+	GetStringMap(key string) map[string]interface{}
+	// GetStringMapString 获取值为字符串的map类型的配置项
+	GetStringMapString(key string) map[string]string
+
+	// Load 将配置项解析到结构体val中，结构体字段使用yaml标签，key为空时解析整个配置
+	Load(key string, val interface{}) error
+
+	// Reload 重新读取所有的配置来源，并通知订阅了发生变化的配置项的回调
+	// 读取失败时保留当前的配置，同时发生的多次重新加载依次执行
+	Reload() error
+	// Watch 订阅prefix下配置项的变化，prefix为空时订阅所有配置项
+	// 每次重新加载之后，如果prefix下有配置项发生了变化，会调用一次callback，传入所有发生变化的配置项
+	// callback在重新加载的过程中依次调用，不能在callback中调用Reload，返回的函数用于取消订阅
+	Watch(prefix string, callback func(changes []ConfigChange)) (cancel func())
+}
+
+// ConfigChange 描述一个配置项的变化，新增的配置项Old为nil，删除的配置项New为nil
+type ConfigChange struct {
+	// Key 发生变化的配置项，是点号分隔的完整路径
+	Key string
+	Old interface{}
+	New interface{}
+}
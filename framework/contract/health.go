@@ -0,0 +1,71 @@
+package contract
+
+import (
+	"context"
+	"time"
+)
+
+// HealthKey 健康检查服务的关键字凭证
+const HealthKey = "web:health"
+
+// 健康检查的状态
+const (
+	// HealthUp 所有检查都通过
+	HealthUp = "up"
+	// HealthDegraded 只有非关键的检查失败，服务仍然可以处理请求
+	HealthDegraded = "degraded"
+	// HealthDown 存在失败的关键检查，或者服务正在关闭
+	HealthDown = "down"
+)
+
+// HealthChecker 是容器中的服务可以选择实现的接口，实现了这个接口的已经实例化的单例会参与就绪检查
+// ctx 带有健康检查服务设置的超时时间，检查失败时返回error
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// HealthCritical 是实现了 HealthChecker 的服务可以选择实现的接口，没有实现时检查是关键的
+// 关键的检查失败时服务不再就绪，非关键的检查失败时服务只是降级
+type HealthCritical interface {
+	HealthCritical() bool
+}
+
+// HealthCheck 是一项检查的结果
+type HealthCheck struct {
+	// Name 检查的名字，来自容器的检查使用服务的关键字凭证
+	Name string `json:"name"`
+	// Status 检查的状态，HealthUp 或者 HealthDown
+	Status string `json:"status"`
+	// Critical 是否是关键的检查
+	Critical bool `json:"critical"`
+	// Error 检查失败的原因
+	Error string `json:"error,omitempty"`
+	// Duration 检查的耗时
+	Duration time.Duration `json:"duration_ns"`
+	// CheckedAt 检查的时间，结果被缓存时是缓存的结果的检查时间
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// HealthReport 是健康检查的汇总结果
+type HealthReport struct {
+	// Status 汇总的状态，是 HealthUp、HealthDegraded、HealthDown 之一
+	Status string `json:"status"`
+	// Checks 每一项检查的结果，按照名字排序
+	Checks []HealthCheck `json:"checks,omitempty"`
+}
+
+// Health 健康检查服务，提供Kubernetes风格的存活检查和就绪检查
+type Health interface {
+	// AddCheck 注册一个不属于容器服务的检查，例如外部依赖的连通性，同名的检查会被替换
+	AddCheck(name string, critical bool, check func(ctx context.Context) error)
+
+	// Live 存活检查，进程能够处理请求就是存活的，不执行任何检查，避免依赖故障导致进程被重启
+	Live(ctx context.Context) HealthReport
+	// Ready 就绪检查，执行所有的检查并汇总，服务正在关闭时直接返回 HealthDown
+	Ready(ctx context.Context) HealthReport
+
+	// SetShuttingDown 标记服务正在关闭，之后就绪检查总是失败，让负载均衡不再转发新的请求
+	SetShuttingDown()
+	// IsShuttingDown 服务是否正在关闭
+	IsShuttingDown() bool
+}
@@ -0,0 +1,83 @@
+package contract
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// LogKey 日志服务的关键字凭证
+const LogKey = "web:log"
+
+// LogLevel 日志级别，级别越高越严重
+type LogLevel int
+
+const (
+	// DebugLevel 调试信息，生产环境一般不输出
+	DebugLevel LogLevel = iota
+	// InfoLevel 正常运行的信息
+	InfoLevel
+	// WarnLevel 需要注意但是不影响运行的问题
+	WarnLevel
+	// ErrorLevel 运行出错，需要处理
+	ErrorLevel
+)
+
+func (l LogLevel) String() string {
+	switch l {
+	case DebugLevel:
+		return "debug"
+	case InfoLevel:
+		return "info"
+	case WarnLevel:
+		return "warn"
+	case ErrorLevel:
+		return "error"
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// ParseLogLevel 解析日志级别的名字，不区分大小写，warning 等同于 warn
+func ParseLogLevel(name string) (LogLevel, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return DebugLevel, nil
+	case "info":
+		return InfoLevel, nil
+	case "warn", "warning":
+		return WarnLevel, nil
+	case "error":
+		return ErrorLevel, nil
+	}
+	return InfoLevel, fmt.Errorf("log: unknown level %q", name)
+}
+
+// LogEntry 是一条结构化的日志
+type LogEntry struct {
+	Level  LogLevel
+	Time   time.Time
+	Msg    string
+	Fields map[string]interface{}
+}
+
+// Log 日志服务，提供分级的结构化日志，所有的日志输出到同一组配置好的输出目标
+// ctx 用于携带请求相关的信息，可以为nil
+type Log interface {
+	// Debug 输出调试级别的日志
+	Debug(ctx context.Context, msg string, fields map[string]interface{})
+	// Info 输出信息级别的日志
+	Info(ctx context.Context, msg string, fields map[string]interface{})
+	// Warn 输出警告级别的日志
+	Warn(ctx context.Context, msg string, fields map[string]interface{})
+	// Error 输出错误级别的日志
+	Error(ctx context.Context, msg string, fields map[string]interface{})
+
+	// With 返回一个带有固定字段的日志服务，和原来的日志服务共享输出目标和日志级别
+	With(fields map[string]interface{}) Log
+
+	// SetLevel 设置输出的最低日志级别
+	SetLevel(level LogLevel)
+	// Level 返回输出的最低日志级别
+	Level() LogLevel
+}
@@ -0,0 +1,72 @@
+package contract
+
+import "io"
+
+// MetricsKey 指标服务的关键字凭证
+const MetricsKey = "web:metrics"
+
+// DefaultBuckets 耗时直方图默认的桶，单位为秒，和Prometheus客户端的默认值相同
+var DefaultBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// Counter 只能增加的计数器
+type Counter interface {
+	// Inc 加1
+	Inc()
+	// Add 增加delta，delta不能是负数
+	Add(delta float64)
+}
+
+// Gauge 可以任意变化的值
+type Gauge interface {
+	// Set 设置为v
+	Set(v float64)
+	// Add 增加delta，delta可以是负数
+	Add(delta float64)
+	// Inc 加1
+	Inc()
+	// Dec 减1
+	Dec()
+}
+
+// Histogram 统计观测值的分布
+type Histogram interface {
+	// Observe 记录一个观测值
+	Observe(v float64)
+}
+
+// CounterVec 按照标签区分的一组计数器
+type CounterVec interface {
+	// With 返回标签值为values的计数器，values的数量和顺序需要和注册时的标签名一致
+	With(values ...string) Counter
+}
+
+// GaugeVec 按照标签区分的一组Gauge
+type GaugeVec interface {
+	// With 返回标签值为values的Gauge，values的数量和顺序需要和注册时的标签名一致
+	With(values ...string) Gauge
+}
+
+// HistogramVec 按照标签区分的一组直方图
+type HistogramVec interface {
+	// With 返回标签值为values的直方图，values的数量和顺序需要和注册时的标签名一致
+	With(values ...string) Histogram
+}
+
+// Metrics 指标服务，以Prometheus的文本格式输出所有的指标
+// 同名的指标只注册一次，重复注册时返回已经注册的指标，类型或者标签名不一致时panic
+// 标签值应该是有限的集合，例如路由模板而不是原始的请求路径，否则指标的数量会无限增长
+type Metrics interface {
+	// Counter 注册计数器
+	Counter(name, help string, labels ...string) CounterVec
+	// Gauge 注册Gauge
+	Gauge(name, help string, labels ...string) GaugeVec
+	// Histogram 注册直方图，buckets为空时使用服务配置的桶
+	Histogram(name, help string, buckets []float64, labels ...string) HistogramVec
+	// GaugeFunc 注册在输出时调用fn获取值的Gauge
+	GaugeFunc(name, help string, fn func() float64)
+
+	// Path 暴露指标的路径
+	Path() string
+	// WriteTo 以Prometheus的文本格式输出所有的指标
+	WriteTo(w io.Writer) (int64, error)
+}
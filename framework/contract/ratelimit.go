@@ -0,0 +1,66 @@
+package contract
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RateLimitKey 限流存储服务的关键字凭证
+const RateLimitKey = "web:ratelimit"
+
+// RateLimitAlgorithm 限流算法
+type RateLimitAlgorithm string
+
+const (
+	// TokenBucket 令牌桶，令牌以 Limit/Window 的速度补充，最多积累Burst个，允许短时间的突发
+	TokenBucket RateLimitAlgorithm = "token_bucket"
+	// SlidingWindow 滑动窗口，用上一个窗口和当前窗口的计数估算最近Window之内的请求数，不超过Limit
+	SlidingWindow RateLimitAlgorithm = "sliding_window"
+)
+
+// RateLimitPolicy 限流策略，例如每分钟100个请求
+type RateLimitPolicy struct {
+	// Algorithm 限流算法，为空时使用令牌桶
+	Algorithm RateLimitAlgorithm `yaml:"algorithm"`
+	// Limit 每个Window之内允许的请求数
+	Limit int `yaml:"limit"`
+	// Window 统计的时间窗口
+	Window time.Duration `yaml:"window"`
+	// Burst 令牌桶的容量，为0时等于Limit，滑动窗口不使用
+	Burst int `yaml:"burst"`
+}
+
+// Validate 检查策略是否合法
+func (p RateLimitPolicy) Validate() error {
+	switch p.Algorithm {
+	case "", TokenBucket, SlidingWindow:
+	default:
+		return fmt.Errorf("ratelimit: unknown algorithm %q", p.Algorithm)
+	}
+	if p.Limit <= 0 || p.Window <= 0 || p.Burst < 0 {
+		return fmt.Errorf("ratelimit: invalid policy %d per %v with burst %d", p.Limit, p.Window, p.Burst)
+	}
+	return nil
+}
+
+// RateLimitResult 一次限流判断的结果
+type RateLimitResult struct {
+	// Allowed 是否允许这一次请求
+	Allowed bool
+	// Limit 策略允许的请求数
+	Limit int
+	// Remaining 之后还允许的请求数
+	Remaining int
+	// Reset 配额完全恢复需要的时间
+	Reset time.Duration
+	// RetryAfter 被拒绝时需要等待多久才能再次请求
+	RetryAfter time.Duration
+}
+
+// RateLimitStore 保存限流状态的存储，key的一次判断和扣减需要是原子的
+// 内存存储只在单个进程内有效，多个实例共享配额时使用Redis等共享的存储实现这个接口
+type RateLimitStore interface {
+	// Allow 按照policy判断key在now的这一次请求是否允许，允许时扣减配额
+	Allow(ctx context.Context, key string, policy RateLimitPolicy, now time.Time) (RateLimitResult, error)
+}
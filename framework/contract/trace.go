@@ -0,0 +1,175 @@
+package contract
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// TraceKey 链路追踪服务的关键字凭证
+const TraceKey = "web:trace"
+
+const (
+	// TraceparentHeader W3C Trace Context 中传递调用链路的请求头
+	TraceparentHeader = "traceparent"
+	// TracestateHeader W3C Trace Context 中传递厂商自定义信息的请求头
+	TracestateHeader = "tracestate"
+)
+
+// SpanKind span在调用链路中的角色
+type SpanKind string
+
+const (
+	// SpanKindInternal 进程内部的操作
+	SpanKindInternal SpanKind = "internal"
+	// SpanKindServer 处理收到的请求
+	SpanKindServer SpanKind = "server"
+	// SpanKindClient 调用下游服务
+	SpanKindClient SpanKind = "client"
+)
+
+// SpanStatus span的结果
+type SpanStatus string
+
+const (
+	// SpanStatusUnset 没有设置结果，一般按照成功处理
+	SpanStatusUnset SpanStatus = "unset"
+	// SpanStatusOK 明确标记为成功
+	SpanStatusOK SpanStatus = "ok"
+	// SpanStatusError 失败
+	SpanStatusError SpanStatus = "error"
+)
+
+// SpanContext 是需要在服务之间传递的span的标识
+type SpanContext struct {
+	// TraceID 32位小写十六进制的调用链路ID
+	TraceID string
+	// SpanID 16位小写十六进制的spanID
+	SpanID string
+	// Sampled 调用链路是否被采样，没有被采样的span不会被导出，但是仍然会传递给下游
+	Sampled bool
+	// TraceState 原样传递的tracestate
+	TraceState string
+	// Remote 是否是从请求头中解析出来的
+	Remote bool
+}
+
+// IsValid TraceID和SpanID都合法并且不全为0
+func (sc SpanContext) IsValid() bool {
+	return validID(sc.TraceID, 32) && validID(sc.SpanID, 16)
+}
+
+// Traceparent 按照 W3C Trace Context 的格式输出traceparent
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID + "-" + sc.SpanID + "-" + flags
+}
+
+// ParseTraceparent 解析traceparent，兼容更高版本在后面追加的字段
+func ParseTraceparent(value string) (SpanContext, error) {
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 {
+		return SpanContext{}, fmt.Errorf("trace: invalid traceparent %q", value)
+	}
+	version, traceID, spanID, flags := parts[0], parts[1], parts[2], parts[3]
+	if !isHex(version, 2) || version == "ff" || version == "00" && len(parts) != 4 ||
+		!validID(traceID, 32) || !validID(spanID, 16) || !isHex(flags, 2) {
+		return SpanContext{}, fmt.Errorf("trace: invalid traceparent %q", value)
+	}
+	b, _ := hex.DecodeString(flags)
+	return SpanContext{TraceID: traceID, SpanID: spanID, Sampled: b[0]&1 == 1, Remote: true}, nil
+}
+
+// validID id是长度为n的小写十六进制并且不全为0
+func validID(id string, n int) bool {
+	return isHex(id, n) && strings.Trim(id, "0") != ""
+}
+
+// isHex s是长度为n的小写十六进制
+func isHex(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, ch := range s {
+		if !(ch >= '0' && ch <= '9' || ch >= 'a' && ch <= 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+// SpanEvent 是span中的一个事件
+type SpanEvent struct {
+	Name       string                 `json:"name"`
+	Time       time.Time              `json:"time"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// SpanData 是结束之后交给导出器的span
+type SpanData struct {
+	Name          string                 `json:"name"`
+	Kind          SpanKind               `json:"kind"`
+	Service       string                 `json:"service"`
+	TraceID       string                 `json:"trace_id"`
+	SpanID        string                 `json:"span_id"`
+	ParentSpanID  string                 `json:"parent_span_id,omitempty"`
+	TraceState    string                 `json:"trace_state,omitempty"`
+	Start         time.Time              `json:"start"`
+	End           time.Time              `json:"end"`
+	Attributes    map[string]interface{} `json:"attributes,omitempty"`
+	Events        []SpanEvent            `json:"events,omitempty"`
+	Status        SpanStatus             `json:"status"`
+	StatusMessage string                 `json:"status_message,omitempty"`
+}
+
+// Span 是调用链路中的一个操作，所有的方法都是并发安全的，End之后的修改被忽略
+type Span interface {
+	// SpanContext 返回需要传递的span标识
+	SpanContext() SpanContext
+	// IsRecording 是否记录属性和事件，没有被采样的span不记录
+	IsRecording() bool
+	// SetName 修改span的名字
+	SetName(name string)
+	// SetAttributes 设置属性
+	SetAttributes(attrs map[string]interface{})
+	// AddEvent 添加事件
+	AddEvent(name string, attrs map[string]interface{})
+	// RecordError 把错误记录为exception事件，并且把结果设置为失败
+	RecordError(err error)
+	// SetStatus 设置结果
+	SetStatus(status SpanStatus, msg string)
+	// End 结束span，交给导出器导出
+	End()
+}
+
+// SpanExporter 把结束的span导出到外部的系统，ExportSpans不会被并发调用
+type SpanExporter interface {
+	// ExportSpans 导出一批span
+	ExportSpans(ctx context.Context, spans []SpanData) error
+	// Shutdown 导出器不再使用时释放资源
+	Shutdown(ctx context.Context) error
+}
+
+// Trace 链路追踪服务，span保存在context.Context中，子span以ctx中的span为父span
+// ctx可以直接使用*gin.Context，这时使用请求的context.Context
+type Trace interface {
+	// Start 创建以ctx中的span为父span的span，返回保存了新的span的context.Context
+	// ctx中没有span时开始一个新的调用链路
+	Start(ctx context.Context, name string, kind SpanKind) (context.Context, Span)
+	// SpanFromContext 返回ctx中的span，没有span时返回不记录任何信息的span
+	SpanFromContext(ctx context.Context) Span
+
+	// Extract 从请求头中解析上游传递的span标识，保存到返回的context.Context中，作为之后Start的父span
+	Extract(ctx context.Context, header http.Header) context.Context
+	// Inject 把ctx中的span标识写入请求头，传递给下游服务
+	Inject(ctx context.Context, header http.Header)
+
+	// Flush 导出所有已经结束的span
+	Flush(ctx context.Context) error
+}
@@ -0,0 +1,70 @@
+package framework
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// contractProvider 声明了契约接口的服务提供者
+type contractProvider struct {
+	*instanceProvider
+	contract reflect.Type
+}
+
+func (p *contractProvider) Contract() reflect.Type { return p.contract }
+
+func bindContract(c *MyContainer, name string, isDefer bool, contract reflect.Type, instance interface{}) error {
+	return c.Bind(&contractProvider{
+		instanceProvider: &instanceProvider{testProvider: newTestProvider(name, isDefer), instance: instance},
+		contract:         contract,
+	})
+}
+
+type stringerService struct{}
+
+func (stringerService) String() string { return "stringer" }
+
+func TestMakeAs(t *testing.T) {
+	c := NewContainer()
+	require.NoError(t, bindContract(c, "stringer", true, nil, stringerService{}))
+
+	s, err := MakeAs[fmt.Stringer](c, "stringer")
+	require.NoError(t, err)
+	assert.Equal(t, "stringer", s.String())
+	assert.Equal(t, "stringer", MustMakeAs[fmt.Stringer](c.NewScope(), "stringer").String())
+
+	_, err = MakeAs[error](c, "stringer")
+	var contractErr *ContractError
+	require.ErrorAs(t, err, &contractErr)
+	assert.Equal(t, "stringer", contractErr.Key)
+	assert.EqualError(t, err, "contract stringer: instance of type framework.stringerService does not implement error")
+
+	_, err = MakeAs[int](c, "stringer")
+	assert.EqualError(t, err, "contract stringer: instance of type framework.stringerService is not int")
+
+	_, err = MakeAs[fmt.Stringer](c, "missing")
+	assert.EqualError(t, err, "contract missing not found.")
+	assert.Panics(t, func() { MustMakeAs[error](c, "stringer") })
+}
+
+func TestContractCheckedOnInstantiation(t *testing.T) {
+	c := NewContainer()
+	stringer := ContractOf[fmt.Stringer]()
+
+	// 非延迟实例化的服务在Bind的时候就能发现契约不满足
+	err := bindContract(c, "eager", false, stringer, 42)
+	assert.EqualError(t, err, "contract eager: instance of type int does not implement fmt.Stringer")
+
+	require.NoError(t, bindContract(c, "deferred", true, stringer, nil))
+	_, err = c.Make("deferred")
+	assert.EqualError(t, err, "contract deferred: instance of type <nil> does not implement fmt.Stringer")
+
+	require.NoError(t, bindContract(c, "ok", false, stringer, stringerService{}))
+	err = bindContract(c, "concrete", true, ContractOf[stringerService](), stringerService{})
+	assert.EqualError(t, err, "contract concrete: framework.stringerService is not an interface")
+	assert.False(t, c.IsBind("concrete"))
+}
@@ -0,0 +1,78 @@
+package framework
+
+import (
+	"fmt"
+)
+
+// Decorator 装饰一个刚实例化的服务，返回包装之后的实例，例如在服务外面增加日志、监控、缓存或者重试
+// c 是实例化服务的容器或者作用域，装饰器可以从中获取自己依赖的服务
+// 返回的实例仍然需要满足服务提供者声明的契约接口
+type Decorator func(c Container, instance interface{}) (interface{}, error)
+
+// Decorate 为关键字凭证key注册一个装饰器，这个服务之后每次实例化(单例、Scoped、Transient以及MakeNew)都会经过装饰器
+// 同一个key的多个装饰器按照注册的顺序执行，后注册的装饰器包在外层
+// key是具名实现的Name时对所有具名实现生效，并且先于只对某个具名实现注册的装饰器执行
+// 子容器中的服务先经过父容器注册的装饰器，再经过子容器自己注册的装饰器
+// 从父容器继承的服务由父容器实例化，子容器的装饰器不会生效，所以直接绑定在父容器中的key返回error
+// key是具名实现的Name时仍然可以注册，只对子容器自己绑定的具名实现生效
+// 已经实例化的单例不会被重新装饰，这时返回error，非延迟实例化的服务需要在Bind之前注册装饰器
+func (c *MyContainer) Decorate(key string, decorator Decorator) error {
+	if decorator == nil {
+		return fmt.Errorf("contract %s: decorator is nil", key)
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.shutdown {
+		return ErrContainerShutdown
+	}
+	if _, own := c.ownKey(key); !own && c.parent != nil && c.parent.isBoundAt(key) {
+		return fmt.Errorf("contract %s is bound in the parent container and can not be decorated in a child container", key)
+	}
+	for _, k := range append([]string{key}, c.variants[key]...) {
+		if _, ok := c.instances[k]; ok {
+			return fmt.Errorf("contract %s is already instantiated and can not be decorated", k)
+		}
+	}
+	c.decorators[key] = append(c.decorators[key], decorator)
+	return nil
+}
+
+// isBoundAt key是否直接绑定在这个容器或者祖先容器中，不包括通过具名实现选择的默认实现
+func (c *MyContainer) isBoundAt(key string) bool {
+	c.lock.RLock()
+	_, ok := c.providers[key]
+	c.lock.RUnlock()
+	if ok || c.parent == nil {
+		return ok
+	}
+	return c.parent.isBoundAt(key)
+}
+
+// decoratorsOf 返回服务提供者的实例需要经过的所有装饰器，按照执行的顺序排列，调用方需要持有锁
+func (c *MyContainer) decoratorsOf(sp ServiceProvider) []Decorator {
+	var decorators []Decorator
+	if c.parent != nil {
+		c.parent.lock.RLock()
+		decorators = c.parent.decoratorsOf(sp)
+		c.parent.lock.RUnlock()
+	}
+	decorators = append(decorators, c.decorators[sp.Name()]...)
+	if key := providerKey(sp); key != sp.Name() {
+		decorators = append(decorators, c.decorators[key]...)
+	}
+	return decorators
+}
+
+// decorate 在容器in中依次使用装饰器包装服务实例
+func (c *MyContainer) decorate(in Container, sp ServiceProvider, instance interface{}) (interface{}, error) {
+	c.lock.RLock()
+	decorators := c.decoratorsOf(sp)
+	c.lock.RUnlock()
+	for _, decorator := range decorators {
+		var err error
+		if instance, err = decorator(in, instance); err != nil {
+			return nil, fmt.Errorf("contract %s decorate: %w", providerKey(sp), err)
+		}
+	}
+	return instance, nil
+}
@@ -0,0 +1,137 @@
+package framework
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// wrap 返回一个把字符串实例包装为 name(instance) 的装饰器
+func wrap(name string) Decorator {
+	return func(c Container, instance interface{}) (interface{}, error) {
+		return fmt.Sprintf("%s(%v)", name, instance), nil
+	}
+}
+
+func TestDecorateAppliesInOrder(t *testing.T) {
+	c := NewContainer()
+	require.NoError(t, c.Decorate("greeter", wrap("log")))
+	require.NoError(t, c.Decorate("greeter", wrap("retry")))
+	bindInstance(t, c, "greeter", "hello")
+
+	ins, err := c.Make("greeter")
+	require.NoError(t, err)
+	assert.Equal(t, "retry(log(hello))", ins)
+
+	ins, err = c.MakeNew("greeter")
+	require.NoError(t, err)
+	assert.Equal(t, "retry(log(hello))", ins)
+
+	infos := c.Bindings()
+	require.Len(t, infos, 1)
+	assert.Equal(t, 2, infos[0].Decorators)
+}
+
+func TestDecoratorPullsDependencies(t *testing.T) {
+	c := NewContainer()
+	bindInstance(t, c, "prefix", "metrics")
+	require.NoError(t, c.Decorate("greeter", func(c Container, instance interface{}) (interface{}, error) {
+		prefix, err := c.Make("prefix")
+		if err != nil {
+			return nil, err
+		}
+		return fmt.Sprintf("%v(%v)", prefix, instance), nil
+	}))
+	events := &[]string{}
+	bindLifetime(t, c, events, "scoped", Scoped)
+	require.NoError(t, c.Decorate("scoped", func(c Container, instance interface{}) (interface{}, error) {
+		_, ok := c.(*Scope)
+		assert.True(t, ok, "scoped service is decorated in its scope")
+		return instance, nil
+	}))
+	bindInstance(t, c, "greeter", "hello")
+
+	ins, err := c.Make("greeter")
+	require.NoError(t, err)
+	assert.Equal(t, "metrics(hello)", ins)
+
+	scope := c.NewScope()
+	_, err = scope.Make("scoped")
+	require.NoError(t, err)
+}
+
+func TestDecorateVariantsAndChildren(t *testing.T) {
+	c := NewContainer()
+	require.NoError(t, c.Decorate("notify@sms", func(c Container, instance interface{}) (interface{}, error) {
+		return notifier("retry-" + instance.(fmt.Stringer).String()), nil
+	}))
+	require.NoError(t, c.Decorate("notify", func(c Container, instance interface{}) (interface{}, error) {
+		return notifier("log-" + instance.(fmt.Stringer).String()), nil
+	}))
+	require.NoError(t, c.Bind(newVariant("email", false)))
+	require.NoError(t, c.Bind(newVariant("sms", false)))
+
+	email, err := c.Make("notify@email")
+	require.NoError(t, err)
+	assert.Equal(t, notifier("log-email"), email)
+	sms, err := c.Make("notify@sms")
+	require.NoError(t, err)
+	assert.Equal(t, notifier("retry-log-sms"), sms)
+
+	// 子容器中的服务先经过父容器的装饰器
+	child := c.NewChild()
+	require.NoError(t, child.Decorate("notify", func(c Container, instance interface{}) (interface{}, error) {
+		return notifier("child-" + instance.(fmt.Stringer).String()), nil
+	}))
+	require.NoError(t, child.Bind(newVariant("push", false)))
+	push, err := child.Make("notify@push")
+	require.NoError(t, err)
+	assert.Equal(t, notifier("child-log-push"), push)
+	// 继承的单例在父容器中实例化，不经过子容器的装饰器
+	email, err = child.Make("notify@email")
+	require.NoError(t, err)
+	assert.Equal(t, notifier("log-email"), email)
+
+	// 只绑定在父容器中的服务不能在子容器中装饰，否则装饰器永远不会执行
+	assert.EqualError(t, child.Decorate("notify@email", wrap("child")),
+		"contract notify@email is bound in the parent container and can not be decorated in a child container")
+	// 子容器覆盖了绑定之后可以装饰
+	other := c.NewChild()
+	require.NoError(t, other.Bind(newVariant("email", false)))
+	require.NoError(t, other.Decorate("notify@email", func(c Container, instance interface{}) (interface{}, error) {
+		return notifier("child-" + instance.(fmt.Stringer).String()), nil
+	}))
+	email, err = other.Make("notify@email")
+	require.NoError(t, err)
+	assert.Equal(t, notifier("child-log-email"), email)
+}
+
+func TestDecorateErrors(t *testing.T) {
+	c := NewContainer()
+	assert.EqualError(t, c.Decorate("greeter", nil), "contract greeter: decorator is nil")
+
+	// 装饰之后的实例仍然需要满足契约
+	require.NoError(t, c.Decorate("notify", wrap("log")))
+	require.NoError(t, c.Bind(newVariant("email", false)))
+	_, err := c.Make("notify")
+	var contractErr *ContractError
+	assert.ErrorAs(t, err, &contractErr)
+
+	failed := errors.New("boom")
+	require.NoError(t, c.Decorate("greeter", func(Container, interface{}) (interface{}, error) {
+		return nil, failed
+	}))
+	bindInstance(t, c, "greeter", "hello")
+	_, err = c.Make("greeter")
+	assert.ErrorIs(t, err, failed)
+	assert.EqualError(t, err, "contract greeter decorate: boom")
+
+	// 已经实例化的单例不能再装饰
+	bindInstance(t, c, "eager", "value")
+	_, err = c.Make("eager")
+	require.NoError(t, err)
+	assert.EqualError(t, c.Decorate("eager", wrap("log")), "contract eager is already instantiated and can not be decorated")
+}
@@ -0,0 +1,60 @@
+package gin
+
+import (
+	"net/http"
+
+	"github.com/RZXBxie/web_server/framework"
+)
+
+// ContainerHandler 以json格式输出服务容器中所有绑定的服务的信息
+// 包括服务提供者的类型、是否延迟实例化、生命周期、是否已经实例化以及实例化耗时
+func ContainerHandler() HandlerFunc {
+	return func(c *Context) {
+		c.ISetStatus(http.StatusOK).IJson(c.container.Bindings())
+	}
+}
+
+// DebugContainer 在debug模式下把 ContainerHandler 挂载到relativePath，其他模式下不注册任何路由
+// 服务容器的信息包含内部实现细节，不应该在生产环境暴露
+func (group *RouterGroup) DebugContainer(relativePath string) IRoutes {
+	if !IsDebugging() {
+		return group.returnObj()
+	}
+	return group.GET(relativePath, ContainerHandler())
+}
+
+// Container 返回路由组使用的服务容器，没有为路由组设置服务容器时返回Engine的服务容器
+func (group *RouterGroup) Container() framework.Container {
+	if group.container != nil {
+		return group.container
+	}
+	return group.engine.container
+}
+
+// WithContainer 设置路由组使用的服务容器，一般是 Container().NewChild() 创建的子容器，用来为路由组覆盖指定的服务
+// 只影响之后在这个路由组以及它的子路由组中注册的路由
+func (group *RouterGroup) WithContainer(container framework.Container) *RouterGroup {
+	group.container = container
+	return group
+}
+
+// SetContainer 替换Engine的服务容器，没有设置服务容器的路由组都会使用它
+// 这个方法不是并发安全的，只应该在初始化或者测试中调用，例如在测试中用子容器把某个服务替换为fake实现
+func (engine *Engine) SetContainer(container framework.Container) {
+	engine.container = container
+}
+
+// useContainer 让请求使用指定的服务容器
+func useContainer(container framework.Container) HandlerFunc {
+	return func(c *Context) {
+		c.setContainer(container)
+		c.Next()
+	}
+}
+
+// MustInject 使用路由组的服务容器为controller注入服务，注入规则见 framework.Inject
+// 一般在注册controller的方法作为路由之前调用，依赖的服务不存在或者实例化失败时panic，让问题在启动时暴露
+func (group *RouterGroup) MustInject(controller interface{}) *RouterGroup {
+	framework.MustInject(group.Container(), controller)
+	return group
+}
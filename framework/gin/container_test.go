@@ -0,0 +1,122 @@
+package gin
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDebugContainer(t *testing.T) {
+	SetMode(DebugMode)
+	defer SetMode(TestMode)
+	router := New()
+	require.NoError(t, router.Bind(&scopedServiceProvider{}))
+	router.DebugContainer("/debug/container")
+
+	w := PerformRequest(router, http.MethodGet, "/debug/container")
+	assert.Equal(t, http.StatusOK, w.Code)
+	var infos []framework.BindingInfo
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &infos))
+	require.Len(t, infos, 1)
+	assert.Equal(t, "scoped", infos[0].Key)
+	assert.Equal(t, "*gin.scopedServiceProvider", infos[0].Provider)
+	assert.Equal(t, "scoped", infos[0].Lifetime)
+}
+
+func TestDebugContainerReleaseMode(t *testing.T) {
+	SetMode(ReleaseMode)
+	defer SetMode(TestMode)
+	router := New()
+	router.DebugContainer("/debug/container")
+
+	w := PerformRequest(router, http.MethodGet, "/debug/container")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+// greeterProvider 提供一个固定字符串的服务
+type greeterProvider struct {
+	greeting string
+}
+
+func (p *greeterProvider) Name() string                             { return "greeter" }
+func (p *greeterProvider) IsDefer() bool                            { return true }
+func (p *greeterProvider) Params(framework.Container) []interface{} { return nil }
+func (p *greeterProvider) Boot(framework.Container) error           { return nil }
+
+func (p *greeterProvider) Register(framework.Container) framework.NewInstance {
+	return func(...interface{}) (interface{}, error) {
+		return p.greeting, nil
+	}
+}
+
+func greet(c *Context) {
+	c.ISetOkStatus().IText("%s", c.MustMake("greeter"))
+}
+
+func TestRouterGroupWithContainer(t *testing.T) {
+	router := New()
+	require.NoError(t, router.Bind(&greeterProvider{greeting: "hello"}))
+	router.GET("/", greet)
+
+	child := router.Container().NewChild()
+	require.NoError(t, child.Bind(&greeterProvider{greeting: "hello admin"}))
+	admin := router.Group("/admin").WithContainer(child)
+	admin.Use(func(c *Context) {
+		c.Set("middleware", c.MustMake("greeter"))
+	})
+	admin.GET("/", greet)
+	admin.Group("/users").GET("/", greet)
+
+	assert.Equal(t, "hello", PerformRequest(router, http.MethodGet, "/").Body.String())
+	assert.Equal(t, "hello admin", PerformRequest(router, http.MethodGet, "/admin/").Body.String())
+	assert.Equal(t, "hello admin", PerformRequest(router, http.MethodGet, "/admin/users/").Body.String())
+	// 请求结束之后Context回到池中，下一个请求重新使用Engine的服务容器
+	assert.Equal(t, "hello", PerformRequest(router, http.MethodGet, "/").Body.String())
+	assert.Same(t, child, admin.Container())
+}
+
+func TestEngineSetContainer(t *testing.T) {
+	router := New()
+	require.NoError(t, router.Bind(&greeterProvider{greeting: "hello"}))
+	router.GET("/", greet)
+
+	root := router.Container()
+	fake := root.NewChild()
+	require.NoError(t, fake.Bind(&greeterProvider{greeting: "fake"}))
+	router.SetContainer(fake)
+	assert.Equal(t, "fake", PerformRequest(router, http.MethodGet, "/").Body.String())
+
+	router.SetContainer(root)
+	assert.Equal(t, "hello", PerformRequest(router, http.MethodGet, "/").Body.String())
+}
+
+type greetController struct {
+	Greeting string `inject:"greeter"`
+}
+
+func (g *greetController) Greet(c *Context) {
+	c.ISetOkStatus().IText("%s", g.Greeting)
+}
+
+func TestRouterGroupMustInject(t *testing.T) {
+	router := New()
+	require.NoError(t, router.Bind(&greeterProvider{greeting: "hello"}))
+	child := router.Container().NewChild()
+	require.NoError(t, child.Bind(&greeterProvider{greeting: "hello admin"}))
+
+	ctrl, adminCtrl := &greetController{}, &greetController{}
+	router.MustInject(ctrl).GET("/", ctrl.Greet)
+	router.Group("/admin").WithContainer(child).MustInject(adminCtrl).GET("/", adminCtrl.Greet)
+
+	assert.Equal(t, "hello", PerformRequest(router, http.MethodGet, "/").Body.String())
+	assert.Equal(t, "hello admin", PerformRequest(router, http.MethodGet, "/admin/").Body.String())
+
+	var missing struct {
+		Cache string `inject:"cache"`
+	}
+	assert.Panics(t, func() { router.MustInject(&missing) })
+}
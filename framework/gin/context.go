@@ -5,6 +5,7 @@
 package gin
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io"
@@ -97,6 +98,12 @@ type Context struct {
 	
 	// container Context中保存服务容器
 	container framework.Container
+
+	// scope 是这个请求的服务作用域，第一次make时创建，请求结束时关闭
+	scope framework.Container
+
+	// copied 表示这是Copy得到的Context，没有请求结束的时机关闭作用域，直接使用服务容器
+	copied bool
 }
 
 /************************************/
@@ -118,15 +125,23 @@ func (c *Context) reset() {
 	c.sameSite = 0
 	*c.params = (*c.params)[:0]
 	*c.skippedNodes = (*c.skippedNodes)[:0]
+	// HandleContext 重新进入时关闭之前的作用域，不能继续使用
+	c.closeScope()
+	if c.engine != nil {
+		c.container = c.engine.container
+	}
 }
 
 // Copy returns a copy of the current context that can be safely used outside the request's scope.
 // This has to be used when the context has to be passed to a goroutine.
+// 复制的Context直接从服务容器中获取服务，没有请求的作用域，获取Scoped服务会返回error
 func (c *Context) Copy() *Context {
 	cp := Context{
 		writermem: c.writermem,
 		Request:   c.Request,
 		engine:    c.engine,
+		container: c.container,
+		copied:    true,
 	}
 	
 	cp.writermem.ResponseWriter = nil
@@ -1335,10 +1350,15 @@ func (c *Context) NegotiateFormat(offered ...string) string {
 	if c.Accepted == nil {
 		c.Accepted = parseAccept(c.requestHeader("Accept"))
 	}
-	if len(c.Accepted) == 0 {
+	return negotiateFormat(c.Accepted, offered)
+}
+
+// negotiateFormat 在offered中选择acceptedList可以接受的格式，acceptedList为空时返回offered[0]
+func negotiateFormat(acceptedList []string, offered []string) string {
+	if len(acceptedList) == 0 {
 		return offered[0]
 	}
-	for _, accepted := range c.Accepted {
+	for _, accepted := range acceptedList {
 		for _, offer := range offered {
 			// According to RFC 2616 and RFC 2396, non-ASCII characters are not allowed in headers,
 			// therefore we can just iterate over the string without casting it into []rune
@@ -1428,14 +1448,78 @@ func (engine *Engine) IsBind(key string) bool {
 	return engine.container.IsBind(key)
 }
 
+// Warmup 在服务器开始监听之前并发地实例化服务容器中的单例，keys为空时预热所有延迟实例化的单例
+// debug模式下会打印每个服务的实例化耗时，返回的error包含所有失败的服务
+func (engine *Engine) Warmup(ctx context.Context, timeout time.Duration, keys ...string) ([]framework.WarmupResult, error) {
+	results, err := engine.container.Warmup(ctx, timeout, keys...)
+	debugPrintWarmup(results)
+	return results, err
+}
+
+// Shutdown 停止服务容器中管理的服务，应该在http.Server.Shutdown之后调用，保证请求处理完之后再停止服务
+func (engine *Engine) Shutdown(ctx context.Context) error {
+	return engine.container.Shutdown(ctx)
+}
+
+// requestScope 获取这个请求的服务作用域，不存在时创建，复制的Context返回服务容器
+func (c *Context) requestScope() framework.Container {
+	if c.copied {
+		return c.container
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.scope == nil {
+		c.scope = c.container.NewScope()
+	}
+	return c.scope
+}
+
+// setContainer 切换这个请求使用的服务容器，已经创建的作用域属于之前的容器，会被关闭
+func (c *Context) setContainer(container framework.Container) {
+	if c.container == container {
+		return
+	}
+	c.closeScope()
+	c.container = container
+}
+
+// closeScope 关闭这个请求的服务作用域，停止其中的Scoped服务
+func (c *Context) closeScope() {
+	c.mu.Lock()
+	scope := c.scope
+	c.scope = nil
+	c.mu.Unlock()
+	if scope == nil {
+		return
+	}
+	debugPrintError(scope.Shutdown(context.Background()))
+}
+
+// Make 在请求的作用域中获取服务实例，Scoped服务在同一个请求中只实例化一次
+// 已经实例化的单例直接返回，不需要创建作用域
 func (c *Context) Make(key string) (interface{}, error) {
-	return c.container.Make(key)
+	if ins, ok := framework.CachedInstance(c.container, key); ok {
+		return ins, nil
+	}
+	return c.requestScope().Make(key)
 }
 
 func (c *Context) MustMake(key string) interface{} {
-	return c.container.MustMake(key)
+	if ins, ok := framework.CachedInstance(c.container, key); ok {
+		return ins
+	}
+	return c.requestScope().MustMake(key)
 }
 
 func (c *Context) MakeNew(key string, params []interface{}) (interface{}, error) {
-	return c.container.MakeNew(key, params)
+	return c.requestScope().MakeNew(key, params...)
+}
+
+func (c *Context) TaggedKeys(tag string) []string {
+	return c.container.TaggedKeys(tag)
+}
+
+// MakeTagged 在请求的作用域中获取带有标签tag的所有服务
+func (c *Context) MakeTagged(tag string) ([]interface{}, error) {
+	return c.requestScope().MakeTagged(tag)
 }
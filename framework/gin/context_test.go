@@ -26,6 +26,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/RZXBxie/web_server/framework"
 	"github.com/RZXBxie/web_server/framework/gin/binding"
 	"github.com/RZXBxie/web_server/framework/gin/codec/json"
 	testdata "github.com/RZXBxie/web_server/framework/gin/testdata/protoexample"
@@ -3677,3 +3678,82 @@ func BenchmarkGetMapFromFormData(b *testing.B) {
 		})
 	}
 }
+
+// scopedService 记录自己是否已经被停止
+type scopedService struct{ stopped bool }
+
+func (s *scopedService) Stop(context.Context) error {
+	s.stopped = true
+	return nil
+}
+
+type scopedServiceProvider struct{}
+
+func (p *scopedServiceProvider) Name() string                             { return "scoped" }
+func (p *scopedServiceProvider) IsDefer() bool                            { return true }
+func (p *scopedServiceProvider) Lifetime() framework.Lifetime             { return framework.Scoped }
+func (p *scopedServiceProvider) Params(framework.Container) []interface{} { return nil }
+func (p *scopedServiceProvider) Boot(framework.Container) error           { return nil }
+
+func (p *scopedServiceProvider) Register(framework.Container) framework.NewInstance {
+	return func(...interface{}) (interface{}, error) {
+		return &scopedService{}, nil
+	}
+}
+
+func TestContextMakeScopedService(t *testing.T) {
+	router := New()
+	require.NoError(t, router.Bind(&scopedServiceProvider{}))
+
+	var services []*scopedService
+	router.GET("/", func(c *Context) {
+		first := c.MustMake("scoped").(*scopedService)
+		second, err := c.Make("scoped")
+		require.NoError(t, err)
+		assert.Same(t, first, second)
+		assert.False(t, first.stopped)
+		services = append(services, first)
+	})
+
+	PerformRequest(router, http.MethodGet, "/")
+	PerformRequest(router, http.MethodGet, "/")
+	require.Len(t, services, 2)
+	assert.NotSame(t, services[0], services[1])
+	assert.True(t, services[0].stopped)
+	assert.True(t, services[1].stopped)
+}
+
+func TestContextCopyRejectsScopedService(t *testing.T) {
+	router := New()
+	require.NoError(t, router.Bind(&scopedServiceProvider{}))
+
+	router.GET("/", func(c *Context) {
+		cp := c.Copy()
+		// 复制的Context没有请求的作用域，Scoped服务不会有人停止
+		_, err := cp.Make("scoped")
+		assert.Error(t, err)
+		_, err = c.Make("scoped")
+		assert.NoError(t, err)
+	})
+	PerformRequest(router, http.MethodGet, "/")
+}
+
+func TestContextHandleContextClosesScope(t *testing.T) {
+	router := New()
+	require.NoError(t, router.Bind(&scopedServiceProvider{}))
+
+	var before, after *scopedService
+	router.GET("/old", func(c *Context) {
+		before = c.MustMake("scoped").(*scopedService)
+		c.Request.URL.Path = "/new"
+		router.HandleContext(c)
+	})
+	router.GET("/new", func(c *Context) {
+		after = c.MustMake("scoped").(*scopedService)
+		assert.True(t, before.stopped)
+	})
+	PerformRequest(router, http.MethodGet, "/old")
+	require.NotNil(t, after)
+	assert.NotSame(t, before, after)
+	assert.True(t, after.stopped)
+}
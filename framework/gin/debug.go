@@ -11,6 +11,9 @@ import (
 	"strconv"
 	"strings"
 	"sync/atomic"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework"
 )
 
 const ginSupportMinGoVer = 23
@@ -105,6 +108,20 @@ at initialization. ie. before any route is registered or the router is listening
 `)
 }
 
+func debugPrintWarmup(results []framework.WarmupResult) {
+	if IsDebugging() && len(results) > 0 {
+		var buf strings.Builder
+		for _, result := range results {
+			status := "ok"
+			if result.Err != nil {
+				status = result.Err.Error()
+			}
+			fmt.Fprintf(&buf, "\t- %-25s %12v  %s\n", result.Key, result.Duration.Round(time.Microsecond), status)
+		}
+		debugPrint("Warmed up services (%d): \n%s\n", len(results), buf.String())
+	}
+}
+
 func debugPrintError(err error) {
 	if err != nil && IsDebugging() {
 		fmt.Fprintf(DefaultErrorWriter, "[GIN-debug] [ERROR] %v\n", err)
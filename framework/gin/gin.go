@@ -165,6 +165,10 @@ type Engine struct {
 	// ContextWithFallback enable fallback Context.Deadline(), Context.Done(), Context.Err() and Context.Value() when Context.Request.Context() is not nil.
 	ContextWithFallback bool
 
+	// HonorTimeoutHeader 是否使用客户端在 TimeoutHeader 中声明的超时时间
+	// 只在已经设置了超时时间的路由上生效，并且只能缩短服务端的超时时间
+	HonorTimeoutHeader bool
+
 	delims           render.Delims
 	secureJSONPrefix string
 	HTMLRender       render.HTMLRender
@@ -647,6 +651,8 @@ func (engine *Engine) ServeHTTP(w http.ResponseWriter, req *http.Request) {
 	c.reset()
 
 	engine.handleHTTPRequest(c)
+	// 请求结束，停止这个请求中创建的Scoped服务
+	c.closeScope()
 
 	engine.pool.Put(c)
 }
@@ -741,11 +747,7 @@ func serveError(c *Context, code int, defaultMessage []byte) {
 		return
 	}
 	if c.writermem.Status() == code {
-		c.writermem.Header()["Content-Type"] = mimePlain
-		_, err := c.Writer.Write(defaultMessage)
-		if err != nil {
-			debugPrint("cannot write message to writer during serve error: %v", err)
-		}
+		c.IProblem(NewProblem(code, string(defaultMessage)))
 		return
 	}
 	c.writermem.WriteHeaderNow()
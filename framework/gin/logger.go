@@ -83,6 +83,8 @@ type LogFormatterParams struct {
 	BodySize int
 	// Keys are the keys set on the request's context.
 	Keys map[any]any
+	// RequestID 请求ID，没有使用请求ID中间件时为空
+	RequestID string
 }
 
 // StatusCodeColor is the ANSI color for appropriately logging http status code to a terminal.
@@ -177,13 +179,19 @@ var defaultLogFormatter = func(param LogFormatterParams) string {
 		param.Latency = param.Latency.Truncate(time.Microsecond * 10)
 	}
 
-	return fmt.Sprintf("[GIN] %v |%s %3d %s|%s %8v %s| %15s |%s %-7s %s %#v\n%s",
+	requestID := ""
+	if param.RequestID != "" {
+		requestID = " | " + param.RequestID
+	}
+
+	return fmt.Sprintf("[GIN] %v |%s %3d %s|%s %8v %s| %15s |%s %-7s %s %#v%s\n%s",
 		param.TimeStamp.Format("2006/01/02 - 15:04:05"),
 		statusColor, param.StatusCode, resetColor,
 		latencyColor, param.Latency, resetColor,
 		param.ClientIP,
 		methodColor, param.Method, resetColor,
 		param.Path,
+		requestID,
 		param.ErrorMessage,
 	)
 }
@@ -297,6 +305,7 @@ func LoggerWithConfig(conf LoggerConfig) HandlerFunc {
 		param.ErrorMessage = c.Errors.ByType(ErrorTypePrivate).String()
 
 		param.BodySize = c.Writer.Size()
+		param.RequestID = c.RequestID()
 
 		if raw != "" {
 			path = path + "?" + raw
@@ -0,0 +1,157 @@
+package gin
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+)
+
+const (
+	// MIMEProblemJSON RFC 7807 中json格式的错误响应的Content-Type
+	MIMEProblemJSON = "application/problem+json"
+	// MIMEProblemXML RFC 7807 中xml格式的错误响应的Content-Type
+	MIMEProblemXML = "application/problem+xml"
+)
+
+// problemNamespace RFC 7807 中xml格式的错误响应使用的命名空间
+const problemNamespace = "urn:ietf:rfc:7807"
+
+// Problem 是 RFC 7807 定义的错误响应，通过 IProblem 输出
+// Extensions 中的扩展成员和标准成员一起输出在最外层，和标准成员同名的扩展成员被忽略
+type Problem struct {
+	// Type 标识错误类型的URI，为空时按照about:blank处理
+	Type string
+	// Title 错误类型的简短描述，同一种错误类型的Title应该相同
+	Title string
+	// Status http状态码
+	Status int
+	// Detail 这一次错误的具体描述
+	Detail string
+	// Instance 标识这一次错误的URI，为空时使用请求的路径
+	Instance string
+	// InvalidParams 参数校验失败的列表
+	InvalidParams []InvalidParam
+	// Extensions 扩展成员
+	Extensions map[string]interface{}
+}
+
+// InvalidParam 是一个校验失败的参数
+type InvalidParam struct {
+	Name   string `json:"name" xml:"name"`
+	Reason string `json:"reason" xml:"reason"`
+}
+
+// NewProblem 创建状态码为status的错误响应，Title为状态码的描述
+func NewProblem(status int, detail string) *Problem {
+	return &Problem{Type: "about:blank", Title: http.StatusText(status), Status: status, Detail: detail}
+}
+
+// With 设置扩展成员
+func (p *Problem) With(key string, value interface{}) *Problem {
+	if p.Extensions == nil {
+		p.Extensions = make(map[string]interface{})
+	}
+	p.Extensions[key] = value
+	return p
+}
+
+// members 按照输出的顺序返回所有的成员，空的标准成员不输出
+func (p *Problem) members() ([]string, map[string]interface{}) {
+	values := make(map[string]interface{}, 6+len(p.Extensions))
+	var keys []string
+	add := func(key string, value interface{}, empty bool) {
+		if !empty {
+			keys = append(keys, key)
+			values[key] = value
+		}
+	}
+	add("type", p.Type, p.Type == "")
+	add("title", p.Title, p.Title == "")
+	add("status", p.Status, p.Status == 0)
+	add("detail", p.Detail, p.Detail == "")
+	add("instance", p.Instance, p.Instance == "")
+	add("invalid-params", p.InvalidParams, len(p.InvalidParams) == 0)
+	for _, key := range slices.Sorted(maps.Keys(p.Extensions)) {
+		switch key {
+		case "type", "title", "status", "detail", "instance", "invalid-params":
+			continue
+		}
+		add(key, p.Extensions[key], false)
+	}
+	return keys, values
+}
+
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	_, values := p.members()
+	return json.Marshal(values)
+}
+
+// MarshalXML 按照 RFC 7807 附录A输出，扩展成员输出为同名的元素
+func (p *Problem) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	start := xml.StartElement{Name: xml.Name{Space: problemNamespace, Local: "problem"}}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	keys, values := p.members()
+	for _, key := range keys {
+		element := xml.StartElement{Name: xml.Name{Local: key}}
+		var err error
+		switch value := values[key].(type) {
+		case []InvalidParam:
+			err = e.EncodeElement(struct {
+				Params []InvalidParam `xml:"i"`
+			}{value}, element)
+		case string, int:
+			err = e.EncodeElement(value, element)
+		default:
+			err = e.EncodeElement(fmt.Sprint(value), element)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// IProblem 输出 RFC 7807 格式的错误响应，请求的Accept优先xml时输出xml，否则输出json
+// 还没有输出状态码时使用Problem的状态码，Instance为空时使用请求的路径，有请求ID时添加request_id扩展成员
+func (c *Context) IProblem(problem *Problem) IResponse {
+	p := *problem
+	if p.Instance == "" && c.Request != nil {
+		p.Instance = c.Request.URL.Path
+	}
+	if id := c.RequestID(); id != "" {
+		if _, ok := p.Extensions["request_id"]; !ok {
+			p.Extensions = maps.Clone(p.Extensions)
+			p.With("request_id", id)
+		}
+	}
+	if p.Status > 0 && !c.Writer.Written() {
+		c.Writer.WriteHeader(p.Status)
+	}
+
+	if c.Accepted == nil {
+		c.Accepted = parseAccept(c.requestHeader("Accept"))
+	}
+	contentType, out, err := marshalProblem(&p, c.Accepted)
+	if err != nil {
+		return c.ISetStatus(http.StatusInternalServerError)
+	}
+	c.Writer.Header().Set("Content-Type", contentType)
+	c.Writer.Write(out)
+	return c
+}
+
+// marshalProblem 根据请求可以接受的格式序列化错误响应，返回Content-Type和内容
+func marshalProblem(p *Problem, accepted []string) (string, []byte, error) {
+	switch negotiateFormat(accepted, []string{MIMEJSON, MIMEProblemJSON, MIMEXML, MIMEXML2, MIMEProblemXML}) {
+	case MIMEXML, MIMEXML2, MIMEProblemXML:
+		out, err := xml.Marshal(p)
+		return MIMEProblemXML, out, err
+	}
+	out, err := json.Marshal(p)
+	return MIMEProblemJSON, out, err
+}
@@ -0,0 +1,50 @@
+package gin
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextIProblem(t *testing.T) {
+	router := New()
+	router.GET("/orders/:id", func(c *Context) {
+		c.SetRequestID("req-1")
+		problem := NewProblem(http.StatusUnprocessableEntity, "order can not be paid")
+		problem.Type = "https://example.com/probs/out-of-credit"
+		problem.InvalidParams = []InvalidParam{{Name: "amount", Reason: "must be positive"}}
+		c.IProblem(problem.With("balance", 30).With("status", "ignored"))
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/orders/1")
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, MIMEProblemJSON, w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"type":"https://example.com/probs/out-of-credit","title":"Unprocessable Entity","status":422,
+		"detail":"order can not be paid","instance":"/orders/1","invalid-params":[{"name":"amount","reason":"must be positive"}],
+		"balance":30,"request_id":"req-1"}`, w.Body.String())
+
+	w = PerformRequest(router, http.MethodGet, "/orders/1", header{"Accept", "application/xml"})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Equal(t, MIMEProblemXML, w.Header().Get("Content-Type"))
+	assert.Equal(t, `<problem xmlns="urn:ietf:rfc:7807"><type>https://example.com/probs/out-of-credit</type>`+
+		`<title>Unprocessable Entity</title><status>422</status><detail>order can not be paid</detail><instance>/orders/1</instance>`+
+		`<invalid-params><i><name>amount</name><reason>must be positive</reason></i></invalid-params>`+
+		`<balance>30</balance><request_id>req-1</request_id></problem>`, w.Body.String())
+}
+
+func TestServeErrorProblem(t *testing.T) {
+	router := New()
+	router.HandleMethodNotAllowed = true
+	router.POST("/path", func(c *Context) {})
+
+	w := PerformRequest(router, http.MethodGet, "/missing")
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Equal(t, MIMEProblemJSON, w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"type":"about:blank","title":"Not Found","status":404,"detail":"404 page not found","instance":"/missing"}`, w.Body.String())
+
+	w = PerformRequest(router, http.MethodGet, "/path", header{"Accept", "application/problem+xml"})
+	assert.Equal(t, http.StatusMethodNotAllowed, w.Code)
+	assert.Equal(t, MIMEProblemXML, w.Header().Get("Content-Type"))
+	assert.Contains(t, w.Body.String(), "<title>Method Not Allowed</title>")
+}
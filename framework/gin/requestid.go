@@ -0,0 +1,52 @@
+package gin
+
+import (
+	"context"
+)
+
+// RequestIDHeader 传递请求ID的请求头和响应头
+const RequestIDHeader = "X-Request-ID"
+
+// RequestIDKey 请求ID保存在Context.Keys中使用的key
+const RequestIDKey = "request_id"
+
+// requestIDContextKey 请求ID保存在context.Context中使用的key
+type requestIDContextKey struct{}
+
+// WithRequestID 返回带有请求ID的context.Context，用于把请求ID传递给下游的服务调用
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey{}, id)
+}
+
+// RequestIDFromContext 获取ctx中的请求ID，ctx可以是*Context或者请求的context.Context，不存在时返回空字符串
+func RequestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	if c, ok := ctx.(*Context); ok {
+		return c.RequestID()
+	}
+	id, _ := ctx.Value(requestIDContextKey{}).(string)
+	return id
+}
+
+// RequestID 获取这个请求的请求ID，没有使用请求ID中间件时返回空字符串
+func (c *Context) RequestID() string {
+	if id := c.GetString(RequestIDKey); id != "" {
+		return id
+	}
+	if c.Request != nil {
+		id, _ := c.Request.Context().Value(requestIDContextKey{}).(string)
+		return id
+	}
+	return ""
+}
+
+// SetRequestID 设置这个请求的请求ID，同时保存到Context、请求的context.Context以及响应头中
+func (c *Context) SetRequestID(id string) {
+	c.Set(RequestIDKey, id)
+	if c.Request != nil {
+		c.Request = c.Request.WithContext(WithRequestID(c.Request.Context(), id))
+	}
+	c.Header(RequestIDHeader, id)
+}
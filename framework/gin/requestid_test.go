@@ -0,0 +1,35 @@
+package gin
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestContextRequestID(t *testing.T) {
+	var buffer bytes.Buffer
+	router := New()
+	router.Use(func(c *Context) {
+		c.SetRequestID("req-1")
+		c.Next()
+	}, LoggerWithWriter(&buffer))
+	var fromContext, fromRequest, fromCopy string
+	router.GET("/", func(c *Context) {
+		fromContext = RequestIDFromContext(c)
+		fromRequest = RequestIDFromContext(c.Request.Context())
+		fromCopy = c.Copy().RequestID()
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/")
+	assert.Equal(t, "req-1", w.Header().Get(RequestIDHeader))
+	assert.Equal(t, "req-1", fromContext)
+	assert.Equal(t, "req-1", fromRequest)
+	assert.Equal(t, "req-1", fromCopy)
+	assert.Contains(t, buffer.String(), `"/" | req-1`)
+
+	assert.Empty(t, RequestIDFromContext(context.Background()))
+	assert.Equal(t, "req-2", RequestIDFromContext(WithRequestID(context.Background(), "req-2")))
+}
@@ -33,6 +33,9 @@ type IResponse interface {
 	ISetStatus(code int) IResponse
 
 	ISetOkStatus() IResponse
+
+	// IProblem 输出 RFC 7807 格式的错误响应
+	IProblem(problem *Problem) IResponse
 }
 
 // IJsonp Jsonp输出
@@ -8,7 +8,11 @@ import (
 	"net/http"
 	"path"
 	"regexp"
+	"slices"
 	"strings"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework"
 )
 
 var (
@@ -57,6 +61,14 @@ type RouterGroup struct {
 	basePath string
 	engine   *Engine
 	root     bool
+
+	// container 路由组使用的服务容器，为nil时使用Engine的服务容器
+	container framework.Container
+
+	// timeout 路由组中路由的超时时间，为0时不限制
+	timeout time.Duration
+	// timeoutAt 超时在Handlers中的位置，设置超时时间之前添加的中间件不受超时限制
+	timeoutAt int
 }
 
 var _ IRouter = (*RouterGroup)(nil)
@@ -71,9 +83,12 @@ func (group *RouterGroup) Use(middleware ...HandlerFunc) IRoutes {
 // For example, all the routes that use a common middleware for authorization could be grouped.
 func (group *RouterGroup) Group(relativePath string, handlers ...HandlerFunc) *RouterGroup {
 	return &RouterGroup{
-		Handlers: group.combineHandlers(handlers),
-		basePath: group.calculateAbsolutePath(relativePath),
-		engine:   group.engine,
+		Handlers:  group.combineHandlers(handlers),
+		basePath:  group.calculateAbsolutePath(relativePath),
+		engine:    group.engine,
+		container: group.container,
+		timeout:   group.timeout,
+		timeoutAt: group.timeoutAt,
 	}
 }
 
@@ -86,6 +101,16 @@ func (group *RouterGroup) BasePath() string {
 func (group *RouterGroup) handle(httpMethod, relativePath string, handlers HandlersChain) IRoutes {
 	absolutePath := group.calculateAbsolutePath(relativePath)
 	handlers = group.combineHandlers(handlers)
+	if group.timeout > 0 {
+		// 超时放在设置超时时间之前添加的中间件之后，这些中间件以及全局的Recovery、Logger能看到超时的结果
+		handlers = slices.Insert(handlers, group.timeoutAt, Timeout(group.timeout))
+	}
+	if group.container != nil {
+		// 切换服务容器的handler放在最前面，保证路由上所有的中间件都使用路由组的服务容器
+		handlers = append(HandlersChain{useContainer(group.container)}, handlers...)
+	}
+	// 框架插入的handler也计入数量，超过abortIndex时Abort无法生效
+	assert1(len(handlers) < int(abortIndex), "too many handlers")
 	group.engine.addRoute(httpMethod, absolutePath, handlers)
 	return group.returnObj()
 }
@@ -7,6 +7,7 @@ package gin
 import (
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -142,6 +143,23 @@ func TestRouterGroupTooManyHandlers(t *testing.T) {
 	})
 }
 
+func TestRouterGroupTooManyHandlersWithTimeoutAndContainer(t *testing.T) {
+	router := New()
+	handlers := make([]HandlerFunc, abortIndex-1)
+	group := router.Group("/api").WithTimeout(time.Second)
+	// 加上超时的handler之后达到abortIndex
+	assert.PanicsWithValue(t, "too many handlers", func() {
+		group.GET("/", handlers...)
+	})
+	group.WithTimeout(0).WithContainer(router.Container().NewChild())
+	assert.PanicsWithValue(t, "too many handlers", func() {
+		group.GET("/", handlers...)
+	})
+	assert.NotPanics(t, func() {
+		router.Group("/ok").GET("/", handlers...)
+	})
+}
+
 func TestRouterGroupBadMethod(t *testing.T) {
 	router := New()
 	assert.Panics(t, func() {
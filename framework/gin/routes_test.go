@@ -538,7 +538,7 @@ func TestRouteNotAllowedDisabled(t *testing.T) {
 		c.String(http.StatusTeapot, "responseText")
 	})
 	w = PerformRequest(router, http.MethodGet, "/path")
-	assert.Equal(t, "404 page not found", w.Body.String())
+	assert.Contains(t, w.Body.String(), `"detail":"404 page not found"`)
 	assert.Equal(t, http.StatusNotFound, w.Code)
 }
 
@@ -0,0 +1,294 @@
+package gin
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TimeoutHeader 客户端声明自己愿意等待的时间的请求头，值是 500ms、1.5s 这样的时间间隔或者表示毫秒的整数
+// 只有Engine.HonorTimeoutHeader为true时才使用，并且只能缩短服务端设置的超时时间
+const TimeoutHeader = "X-Request-Timeout"
+
+// Timeout 限制后续handler的处理时间
+// 后续的handler在单独的goroutine中运行，它们的输出先写入缓冲区，在超时之前完成时才输出给客户端
+// 超时时取消请求的context.Context通知handler停止，立即向客户端返回 RFC 7807 格式的504，handler之后的输出被丢弃
+// 返回504之后仍然会等待handler结束才返回，保证Context在放回池中之前不再被使用，所以handler需要响应ctx的取消
+// handler中的panic以 *PanicError 在当前goroutine中重新抛出，交给Recovery处理；超时之后的panic记录在Context.Errors中
+// http.ErrAbortHandler 原样重新抛出
+func Timeout(d time.Duration) HandlerFunc {
+	return func(c *Context) {
+		timeout := d
+		if c.engine != nil && c.engine.HonorTimeoutHeader {
+			if client, ok := parseTimeout(c.GetHeader(TimeoutHeader)); ok && client < timeout {
+				timeout = client
+			}
+		}
+		c.runWithTimeout(timeout)
+	}
+}
+
+// WithTimeout 设置路由组中路由的超时时间，超时的实现见 Timeout，d为0时不限制
+// 只影响之后在这个路由组以及它的子路由组中注册的路由，子路由组可以覆盖继承的超时时间
+// 之前已经添加的中间件不受超时限制，能看到超时的结果，之后添加的中间件和路由的handler一起受超时限制
+// 需要为单个路由设置超时时间时使用 RouterGroup.Timeout
+func (group *RouterGroup) WithTimeout(d time.Duration) *RouterGroup {
+	group.timeout, group.timeoutAt = d, len(group.Handlers)
+	return group
+}
+
+// Timeout 为接下来注册的单个路由设置超时时间，覆盖路由组继承的超时时间，不影响group本身，d为0时这个路由不限制
+// 例如 group.Timeout(time.Second).GET("/slow", handler)
+// 路由组已经设置了超时时间时，超时仍然在原来的位置，否则和 WithTimeout 一样放在已经添加的中间件之后
+func (group *RouterGroup) Timeout(d time.Duration) IRoutes {
+	route := *group
+	route.Handlers = slices.Clip(group.Handlers)
+	route.root = false
+	route.timeout = d
+	if group.timeout <= 0 {
+		route.timeoutAt = len(group.Handlers)
+	}
+	return &route
+}
+
+// parseTimeout 解析 TimeoutHeader 的值，不合法或者不是正数时返回false
+func parseTimeout(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if ms, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(ms) * time.Millisecond, ms > 0
+	}
+	d, err := time.ParseDuration(value)
+	return d, err == nil && d > 0
+}
+
+// Remaining 返回请求剩余的处理时间，用于给下游的调用分配超时时间，请求没有超时时间时返回false
+func (c *Context) Remaining() (time.Duration, bool) {
+	if c.Request == nil {
+		return 0, false
+	}
+	deadline, ok := c.Request.Context().Deadline()
+	if !ok {
+		return 0, false
+	}
+	return time.Until(deadline), true
+}
+
+// runWithTimeout 在超时时间d之内运行后续的handler
+func (c *Context) runWithTimeout(d time.Duration) {
+	ctx, cancel := context.WithTimeout(c.Request.Context(), d)
+	defer cancel()
+	c.Request = c.Request.WithContext(ctx)
+
+	// 超时的响应在handler运行的同时输出，需要的信息提前准备好，不在handler运行时读取Context
+	problem := NewProblem(http.StatusGatewayTimeout, fmt.Sprintf("request did not complete within %v", d))
+	problem.Instance = c.Request.URL.Path
+	if id := c.RequestID(); id != "" {
+		problem.With("request_id", id)
+	}
+	accepted := parseAccept(c.requestHeader("Accept"))
+
+	w := c.Writer
+	tw := &timeoutWriter{w: w, ctx: ctx, header: make(http.Header), status: http.StatusOK, size: -1}
+	c.Writer = tw
+
+	done := make(chan struct{})
+	var p any
+	go func() {
+		defer close(done)
+		defer func() {
+			// 调用栈只能在发生panic的goroutine中获取
+			if p = recover(); p != nil && p != http.ErrAbortHandler {
+				p = &PanicError{Value: p, Stack: debug.Stack()}
+			}
+			tw.finish()
+		}()
+		c.Next()
+	}()
+
+	select {
+	case <-done:
+	case <-ctx.Done():
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) && tw.timeout() {
+		writeTimeout(w, problem, accepted)
+	}
+	<-done
+	c.Writer = w
+	if tw.timedOut {
+		c.Abort()
+		if pe, ok := p.(*PanicError); ok {
+			_ = c.Error(fmt.Errorf("panic after timeout: %w", pe))
+		} else if p != nil {
+			_ = c.Error(fmt.Errorf("panic after timeout: %v", p))
+		}
+		return
+	}
+	if p != nil {
+		panic(p)
+	}
+	tw.flush()
+}
+
+// PanicError 超时控制下的handler在单独的goroutine中发生的panic，Stack是发生panic的goroutine的调用栈
+// 重新抛出之后Recovery和ErrorHandler使用Stack输出日志，而不是重新抛出的位置的调用栈
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func (e *PanicError) Error() string {
+	return fmt.Sprint(e.Value)
+}
+
+// Unwrap panic的值是error时返回它，这样errors.Is和errors.As可以匹配原来的错误
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// writeTimeout 直接向客户端返回504的错误响应，这时handler可能还在运行，所以不能通过Context输出
+func writeTimeout(w ResponseWriter, problem *Problem, accepted []string) {
+	contentType, body, _ := marshalProblem(problem, accepted)
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(http.StatusGatewayTimeout)
+	_, _ = w.Write(body)
+	w.Flush()
+}
+
+// errHijackTimeout 超时控制需要缓冲输出，不支持接管连接
+var errHijackTimeout = errors.New("gin: hijack is not supported under timeout")
+
+// timeoutWriter 缓冲handler的输出，请求超时之后的写入返回 http.ErrHandlerTimeout 并被丢弃
+// header 只在handler的goroutine中使用，handler结束之后才被读取，不需要加锁
+type timeoutWriter struct {
+	w      ResponseWriter
+	ctx    context.Context
+	header http.Header
+	body   bytes.Buffer
+	status int
+	// size 写入的字节数，-1表示还没有写入
+	size     int
+	timedOut bool
+	finished bool
+	late     bool
+	lock     sync.Mutex
+}
+
+var _ ResponseWriter = (*timeoutWriter)(nil)
+
+// timeout 标记已经超时，handler在超时之前已经结束时返回false，这时仍然输出handler的结果
+func (tw *timeoutWriter) timeout() bool {
+	tw.lock.Lock()
+	defer tw.lock.Unlock()
+	if tw.finished && !tw.late {
+		return false
+	}
+	tw.timedOut = true
+	return true
+}
+
+// expired 请求是否已经超时，超时之后handler的写入都会被丢弃，调用方需要持有锁
+func (tw *timeoutWriter) expired() bool {
+	return tw.timedOut || errors.Is(tw.ctx.Err(), context.DeadlineExceeded)
+}
+
+// finish 标记handler已经结束，并记录结束时请求是否已经超时
+func (tw *timeoutWriter) finish() {
+	tw.lock.Lock()
+	defer tw.lock.Unlock()
+	tw.finished, tw.late = true, tw.expired()
+}
+
+// flush handler在超时之前结束，把缓冲的输出写给客户端
+func (tw *timeoutWriter) flush() {
+	dst := tw.w.Header()
+	for key, values := range tw.header {
+		dst[key] = values
+	}
+	tw.w.WriteHeader(tw.status)
+	if tw.size >= 0 {
+		tw.w.WriteHeaderNow()
+		_, _ = tw.w.Write(tw.body.Bytes())
+	}
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.lock.Lock()
+	defer tw.lock.Unlock()
+	if tw.expired() {
+		return 0, http.ErrHandlerTimeout
+	}
+	if tw.size < 0 {
+		tw.size = 0
+	}
+	n, err := tw.body.Write(p)
+	tw.size += n
+	return n, err
+}
+
+func (tw *timeoutWriter) WriteString(s string) (int, error) {
+	return tw.Write([]byte(s))
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.lock.Lock()
+	defer tw.lock.Unlock()
+	if code > 0 && tw.size < 0 && !tw.expired() {
+		tw.status = code
+	}
+}
+
+func (tw *timeoutWriter) WriteHeaderNow() {
+	tw.lock.Lock()
+	defer tw.lock.Unlock()
+	if tw.size < 0 && !tw.expired() {
+		tw.size = 0
+	}
+}
+
+func (tw *timeoutWriter) Status() int {
+	tw.lock.Lock()
+	defer tw.lock.Unlock()
+	return tw.status
+}
+
+func (tw *timeoutWriter) Size() int {
+	tw.lock.Lock()
+	defer tw.lock.Unlock()
+	return tw.size
+}
+
+func (tw *timeoutWriter) Written() bool {
+	return tw.Size() >= 0
+}
+
+// Flush 输出在handler结束之后才会写给客户端，这里什么都不做
+func (tw *timeoutWriter) Flush() {}
+
+func (tw *timeoutWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return nil, nil, errHijackTimeout
+}
+
+func (tw *timeoutWriter) CloseNotify() <-chan bool {
+	return tw.w.CloseNotify()
+}
+
+func (tw *timeoutWriter) Pusher() http.Pusher {
+	return nil
+}
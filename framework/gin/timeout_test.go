@@ -0,0 +1,152 @@
+package gin
+
+import (
+	"bytes"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// remainingHandler 在响应头中输出请求剩余的处理时间，没有超时时间时输出none
+func remainingHandler(c *Context) {
+	remaining, ok := c.Remaining()
+	if !ok {
+		c.ISetHeader("X-Remaining", "none")
+		return
+	}
+	c.ISetHeader("X-Remaining", remaining.Round(time.Second).String())
+}
+
+func TestRouterGroupTimeout(t *testing.T) {
+	router := New()
+	router.GET("/none", remainingHandler)
+	api := router.Group("/api").WithTimeout(5 * time.Second)
+	api.GET("/inherit", remainingHandler)
+	api.Timeout(2*time.Second).GET("/route", remainingHandler)
+	api.Timeout(0).GET("/route/unlimited", remainingHandler)
+	api.Group("/slow").WithTimeout(10*time.Second).GET("/", remainingHandler)
+	api.Group("/unlimited").WithTimeout(0).GET("/", remainingHandler)
+	api.GET("/after", remainingHandler)
+	router.Timeout(time.Second).GET("/root", remainingHandler)
+	router.GET("/root/after", remainingHandler)
+
+	cases := map[string]string{
+		"/none":                "none",
+		"/api/inherit":         "5s",
+		"/api/route":           "2s",
+		"/api/route/unlimited": "none",
+		"/root":                "1s",
+		"/root/after":          "none",
+		"/api/slow/":           "10s",
+		"/api/unlimited/":      "none",
+		"/api/after":           "5s",
+	}
+	for path, want := range cases {
+		w := PerformRequest(router, http.MethodGet, path)
+		assert.Equal(t, http.StatusOK, w.Code, path)
+		assert.Equal(t, want, w.Header().Get("X-Remaining"), path)
+	}
+}
+
+func TestRouterGroupTimeoutExpires(t *testing.T) {
+	var status int
+	router := New()
+	router.Use(func(c *Context) {
+		c.Next()
+		status = c.Writer.Status()
+	})
+	router.Group("/api").WithTimeout(20*time.Millisecond).GET("/", func(c *Context) {
+		<-c.Request.Context().Done()
+	})
+
+	w := PerformRequest(router, http.MethodGet, "/api/")
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Equal(t, http.StatusGatewayTimeout, status)
+	assert.JSONEq(t, `{"type":"about:blank","title":"Gateway Timeout","status":504,"detail":"request did not complete within 20ms","instance":"/api/"}`, w.Body.String())
+}
+
+func TestTimeoutHeader(t *testing.T) {
+	router := New()
+	router.GET("/none", remainingHandler)
+	router.Group("").WithTimeout(5*time.Second).GET("/", remainingHandler)
+
+	// 默认不使用客户端的超时时间
+	w := PerformRequest(router, http.MethodGet, "/", header{TimeoutHeader, "2s"})
+	assert.Equal(t, "5s", w.Header().Get("X-Remaining"))
+
+	router.HonorTimeoutHeader = true
+	cases := map[string]string{
+		"2s":      "2s",
+		"3000":    "3s",
+		"1m":      "5s",
+		"-1s":     "5s",
+		"0":       "5s",
+		"invalid": "5s",
+	}
+	for value, want := range cases {
+		w := PerformRequest(router, http.MethodGet, "/", header{TimeoutHeader, value})
+		assert.Equal(t, want, w.Header().Get("X-Remaining"), value)
+	}
+
+	// 没有设置超时时间的路由不使用客户端的超时时间
+	w = PerformRequest(router, http.MethodGet, "/none", header{TimeoutHeader, "2s"})
+	assert.Equal(t, "none", w.Header().Get("X-Remaining"))
+}
+
+func TestRouterGroupTimeoutMiddleware(t *testing.T) {
+	var outside, inside bool
+	router := New()
+	api := router.Group("/api", func(c *Context) {
+		_, outside = c.Remaining()
+	}).WithTimeout(5 * time.Second)
+	inner := api.Group("/inner")
+	inner.Use(func(c *Context) {
+		_, inside = c.Remaining()
+	})
+	inner.GET("/", remainingHandler)
+	// 单个路由覆盖超时时间时，超时仍然在原来的位置
+	inner.Timeout(time.Second).GET("/fast", remainingHandler)
+
+	w := PerformRequest(router, http.MethodGet, "/api/inner/")
+	assert.Equal(t, "5s", w.Header().Get("X-Remaining"))
+	assert.False(t, outside)
+	assert.True(t, inside)
+
+	outside, inside = false, false
+	w = PerformRequest(router, http.MethodGet, "/api/inner/fast")
+	assert.Equal(t, "1s", w.Header().Get("X-Remaining"))
+	assert.False(t, outside)
+	assert.True(t, inside)
+}
+
+// panicInHandler 用于在调用栈中查找发生panic的位置
+func panicInHandler(c *Context) {
+	panic("boom")
+}
+
+func TestTimeoutPanicKeepsStack(t *testing.T) {
+	var buf bytes.Buffer
+	var recovered any
+	router := New()
+	router.Use(CustomRecoveryWithWriter(&buf, func(c *Context, err any) {
+		recovered = err
+		c.AbortWithStatus(http.StatusInternalServerError)
+	}))
+	router.Group("").WithTimeout(time.Second).GET("/", panicInHandler)
+
+	w := PerformRequest(router, http.MethodGet, "/")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Equal(t, "boom", recovered)
+	assert.Contains(t, buf.String(), "panicInHandler")
+
+	// http.ErrAbortHandler 原样重新抛出
+	router = New()
+	router.Group("").WithTimeout(time.Second).GET("/", func(c *Context) {
+		panic(http.ErrAbortHandler)
+	})
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		PerformRequest(router, http.MethodGet, "/")
+	})
+}
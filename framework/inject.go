@@ -0,0 +1,63 @@
+package framework
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// ContractResolver 能够根据契约接口查找服务的关键字凭证，MyContainer 和 Scope 都实现了这个接口
+type ContractResolver interface {
+	KeyOf(contract reflect.Type) (string, error)
+}
+
+// Inject 把服务注入到target指向的结构体中，带有inject标签的字段会被注入
+//   - `inject:"key"` 使用关键字凭证key获取服务
+//   - `inject:""` 根据字段的类型查找声明了这个契约接口的服务提供者，要求恰好有一个服务提供者声明了这个契约
+//
+// 注入的字段必须是导出的，服务实例必须能够赋值给字段，任何一个字段注入失败都会返回error，已经注入的字段不会回滚
+func Inject(c Container, target interface{}) error {
+	v := reflect.ValueOf(target)
+	if v.Kind() != reflect.Pointer || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("inject: target must be a pointer to struct, got %T", target)
+	}
+	v = v.Elem()
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key, ok := field.Tag.Lookup("inject")
+		if !ok {
+			continue
+		}
+		if !field.IsExported() {
+			return fmt.Errorf("inject: %v.%s is unexported", t, field.Name)
+		}
+		if key == "" {
+			resolver, ok := c.(ContractResolver)
+			if !ok {
+				return fmt.Errorf("inject: %v.%s: container %T can not resolve contract", t, field.Name, c)
+			}
+			var err error
+			if key, err = resolver.KeyOf(field.Type); err != nil {
+				return fmt.Errorf("inject: %v.%s: %w", t, field.Name, err)
+			}
+		}
+		instance, err := c.Make(key)
+		if err != nil {
+			return fmt.Errorf("inject: %v.%s: %w", t, field.Name, err)
+		}
+		value := reflect.ValueOf(instance)
+		if !value.IsValid() || !value.Type().AssignableTo(field.Type) {
+			err := &ContractError{Key: key, Expected: field.Type, Actual: reflect.TypeOf(instance)}
+			return fmt.Errorf("inject: %v.%s: %w", t, field.Name, err)
+		}
+		v.Field(i).Set(value)
+	}
+	return nil
+}
+
+// MustInject 和 Inject 相同，注入失败时panic，一般在启动时注册路由的时候使用
+func MustInject(c Container, target interface{}) {
+	if err := Inject(c, target); err != nil {
+		panic(err)
+	}
+}
@@ -0,0 +1,72 @@
+package framework
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type injectTarget struct {
+	Name     string       `inject:"name"`
+	Stringer fmt.Stringer `inject:""`
+	Skipped  string
+}
+
+func newInjectContainer(t *testing.T) *MyContainer {
+	c := NewContainer()
+	bindInstance(t, c, "name", "web")
+	require.NoError(t, bindContract(c, "stringer", true, ContractOf[fmt.Stringer](), stringerService{}))
+	return c
+}
+
+func TestInject(t *testing.T) {
+	c := newInjectContainer(t)
+
+	target := &injectTarget{Skipped: "keep"}
+	require.NoError(t, Inject(c, target))
+	assert.Equal(t, "web", target.Name)
+	assert.Equal(t, "stringer", target.Stringer.String())
+	assert.Equal(t, "keep", target.Skipped)
+
+	target = &injectTarget{}
+	require.NoError(t, Inject(c.NewScope(), target))
+	assert.Equal(t, "web", target.Name)
+}
+
+func TestInjectErrors(t *testing.T) {
+	c := newInjectContainer(t)
+
+	assert.EqualError(t, Inject(c, injectTarget{}), "inject: target must be a pointer to struct, got framework.injectTarget")
+
+	var missing struct {
+		Cache string `inject:"cache"`
+	}
+	assert.EqualError(t, Inject(c, &missing), "inject: struct { Cache string \"inject:\\\"cache\\\"\" }.Cache: contract cache not found.")
+
+	var unexported struct {
+		name string `inject:"name"`
+	}
+	assert.ErrorContains(t, Inject(c, &unexported), ".name is unexported")
+
+	var mismatch struct {
+		Name int `inject:"name"`
+	}
+	err := Inject(c, &mismatch)
+	var contractErr *ContractError
+	require.ErrorAs(t, err, &contractErr)
+	assert.ErrorContains(t, err, "contract name: instance of type string is not int")
+
+	var unknown struct {
+		Err error `inject:""`
+	}
+	assert.ErrorContains(t, Inject(c, &unknown), "no provider declares contract error")
+
+	require.NoError(t, bindContract(c, "other", true, ContractOf[fmt.Stringer](), stringerService{}))
+	var ambiguous struct {
+		Stringer fmt.Stringer `inject:""`
+	}
+	assert.ErrorContains(t, Inject(c, &ambiguous), "contract fmt.Stringer is declared by multiple providers: other, stringer")
+	assert.Panics(t, func() { MustInject(c, &ambiguous) })
+}
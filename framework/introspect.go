@@ -0,0 +1,111 @@
+package framework
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// makeStat 记录一个服务的实例化统计
+type makeStat struct {
+	// count 实例化成功的次数
+	count int
+	// at 最近一次实例化开始的时间
+	at time.Time
+	// duration 最近一次实例化的耗时，包括Boot和Register
+	duration time.Duration
+}
+
+// BindingInfo 描述容器中一个绑定的服务
+type BindingInfo struct {
+	// Key 服务的关键字凭证
+	Key string `json:"key"`
+	// Name 服务提供者的名字，具名实现的Key包含了实现的名字，Name不包含
+	Name string `json:"name"`
+	// Variant 具名实现的名字
+	Variant string `json:"variant,omitempty"`
+	// Default 是否是Name下被选中的默认实现
+	Default bool `json:"default,omitempty"`
+	// Tags 服务的标签
+	Tags []string `json:"tags,omitempty"`
+	// Provider 服务提供者的类型
+	Provider string `json:"provider"`
+	// Defer 是否延迟实例化
+	Defer bool `json:"defer"`
+	// Lifetime 服务实例的生命周期
+	Lifetime string `json:"lifetime"`
+	// Contract 服务声明的契约接口，没有声明时为空
+	Contract string `json:"contract,omitempty"`
+	// Depends 服务声明的依赖
+	Depends []string `json:"depends,omitempty"`
+	// Decorators 服务实例化时经过的装饰器的数量，包括父容器中注册的装饰器
+	Decorators int `json:"decorators,omitempty"`
+	// Inherited 是否是从父容器继承的绑定
+	Inherited bool `json:"inherited,omitempty"`
+	// Pending 非延迟实例化的服务是否还在等待依赖绑定
+	Pending bool `json:"pending"`
+	// Instantiated 单例是否已经实例化，其他生命周期的服务是否实例化过
+	Instantiated bool `json:"instantiated"`
+	// Instances 实例化成功的次数
+	Instances int `json:"instances"`
+	// InstantiatedAt 最近一次实例化的时间
+	InstantiatedAt *time.Time `json:"instantiated_at,omitempty"`
+	// Duration 最近一次实例化的耗时
+	Duration time.Duration `json:"duration_ns,omitempty"`
+}
+
+// Bindings 子容器返回的信息中包含从父容器继承的绑定
+func (c *MyContainer) Bindings() []BindingInfo {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+
+	infos := make([]BindingInfo, 0, len(c.providers))
+	for key, sp := range c.providers {
+		lifetime := providerLifetime(sp)
+		info := BindingInfo{
+			Key:      key,
+			Name:     sp.Name(),
+			Variant:  providerVariant(sp),
+			Tags:     providerTags(sp),
+			Provider: fmt.Sprintf("%T", sp),
+			Defer:    sp.IsDefer(),
+			Lifetime: lifetime.String(),
+			Depends:  providerDepends(sp),
+			Pending:  slices.Contains(c.pending, key),
+		}
+		info.Decorators = len(c.decoratorsOf(sp))
+		if contract := providerContract(sp); contract != nil {
+			info.Contract = contract.String()
+		}
+		if info.Variant != "" {
+			defaultKey, _ := c.ownKey(sp.Name())
+			info.Default = defaultKey == key
+		}
+		if stat, ok := c.stats[key]; ok {
+			at := stat.at
+			info.Instances = stat.count
+			info.InstantiatedAt = &at
+			info.Duration = stat.duration
+		}
+		if lifetime == Singleton {
+			_, info.Instantiated = c.instances[key]
+		} else {
+			info.Instantiated = info.Instances > 0
+		}
+		infos = append(infos, info)
+	}
+	// 子容器还包含父容器中没有被覆盖的绑定
+	if c.parent != nil {
+		for _, info := range c.parent.Bindings() {
+			if _, ok := c.providers[info.Key]; !ok {
+				info.Inherited = true
+				infos = append(infos, info)
+			}
+		}
+	}
+	slices.SortFunc(infos, func(a, b BindingInfo) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return infos
+}
@@ -0,0 +1,47 @@
+package framework
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainerBindings(t *testing.T) {
+	c := NewContainer()
+	events := &[]string{}
+	require.NoError(t, bindContract(c, "stringer", true, ContractOf[fmt.Stringer](), stringerService{}))
+	bindLifetime(t, c, events, "scoped", Scoped, "stringer")
+	require.NoError(t, c.Bind(newTestProvider("eager", false, "missing")))
+
+	infos := c.Bindings()
+	require.Len(t, infos, 3)
+	assert.Equal(t, BindingInfo{
+		Key:      "eager",
+		Name:     "eager",
+		Provider: "*framework.testProvider",
+		Lifetime: "singleton",
+		Depends:  []string{"missing"},
+		Pending:  true,
+	}, infos[0])
+	assert.Equal(t, "scoped", infos[1].Key)
+	assert.Equal(t, "scoped", infos[1].Lifetime)
+	assert.False(t, infos[1].Instantiated)
+	assert.Equal(t, "stringer", infos[2].Key)
+	assert.Equal(t, "fmt.Stringer", infos[2].Contract)
+	assert.True(t, infos[2].Defer)
+	assert.Nil(t, infos[2].InstantiatedAt)
+
+	_, err := c.NewScope().Make("scoped")
+	require.NoError(t, err)
+	_, err = c.NewScope().Make("scoped")
+	require.NoError(t, err)
+
+	infos = c.NewScope().Bindings()
+	assert.True(t, infos[1].Instantiated)
+	assert.Equal(t, 2, infos[1].Instances)
+	assert.True(t, infos[2].Instantiated)
+	assert.Equal(t, 1, infos[2].Instances)
+	assert.NotNil(t, infos[2].InstantiatedAt)
+}
@@ -0,0 +1,88 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// ErrContainerShutdown 容器已经关闭，不能再实例化服务
+var ErrContainerShutdown = errors.New("container is shut down")
+
+// ServiceStarter 是服务实例可以选择实现的接口，容器在实例化单例之后调用Start
+// 传入的ctx会在容器Shutdown的时候被取消，服务启动的后台goroutine可以监听它退出
+// Start 返回error时这次实例化失败，实例不会被缓存
+type ServiceStarter interface {
+	Start(ctx context.Context) error
+}
+
+// ServiceStopper 是服务实例可以选择实现的接口，容器Shutdown的时候调用Stop
+// 传入的ctx带有关闭的截止时间，Stop 需要在截止时间之前释放连接池、文件、goroutine等资源
+type ServiceStopper interface {
+	Stop(ctx context.Context) error
+}
+
+// startInstance 启动服务实例，没有实现ServiceStarter的实例不需要启动
+func startInstance(ctx context.Context, key string, instance interface{}) error {
+	starter, ok := instance.(ServiceStarter)
+	if !ok {
+		return nil
+	}
+	if err := starter.Start(ctx); err != nil {
+		return fmt.Errorf("contract %s start: %w", key, err)
+	}
+	return nil
+}
+
+// stopInstance 停止服务实例，优先使用ServiceStopper，其次使用io.Closer
+func stopInstance(ctx context.Context, key string, instance interface{}) error {
+	var err error
+	switch ins := instance.(type) {
+	case ServiceStopper:
+		err = ins.Stop(ctx)
+	case io.Closer:
+		err = ins.Close()
+	default:
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("contract %s stop: %w", key, err)
+	}
+	return nil
+}
+
+// Shutdown 按照实例化相反的顺序停止容器中的单例，也就是先停止依赖别人的服务，再停止被依赖的服务
+// 重新绑定时被替换的单例也在这里停止
+// ctx 到期之后不再停止剩下的服务，返回的error中会包含ctx的错误
+// Shutdown 之后容器不能再实例化服务
+func (c *MyContainer) Shutdown(ctx context.Context) error {
+	c.lock.Lock()
+	if c.shutdown {
+		c.lock.Unlock()
+		return nil
+	}
+	c.shutdown = true
+	c.cancel()
+	order := c.order
+	c.order = nil
+	c.instances = make(map[string]interface{})
+	c.lock.Unlock()
+
+	return stopInstances(ctx, order)
+}
+
+// stopInstances 按照order相反的顺序停止实例，ctx 到期之后不再停止剩下的实例
+func stopInstances(ctx context.Context, order []orderedInstance) error {
+	var errs []error
+	for i := len(order) - 1; i >= 0; i-- {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("shutdown: %d services not stopped: %w", i+1, err))
+			break
+		}
+		if err := stopInstance(ctx, order[i].key, order[i].instance); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,170 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lifecycleService 记录启动和停止的顺序
+type lifecycleService struct {
+	name    string
+	events  *[]string
+	mu      *sync.Mutex
+	startFn func(ctx context.Context) error
+	stopErr error
+}
+
+func (s *lifecycleService) record(event string) {
+	s.mu.Lock()
+	*s.events = append(*s.events, event+" "+s.name)
+	s.mu.Unlock()
+}
+
+func (s *lifecycleService) Start(ctx context.Context) error {
+	s.record("start")
+	if s.startFn != nil {
+		return s.startFn(ctx)
+	}
+	return nil
+}
+
+func (s *lifecycleService) Stop(ctx context.Context) error {
+	s.record("stop")
+	return s.stopErr
+}
+
+// closerService 只实现了io.Closer
+type closerService struct{ closed bool }
+
+func (s *closerService) Close() error {
+	s.closed = true
+	return nil
+}
+
+// instanceProvider 返回固定实例的服务提供者
+type instanceProvider struct {
+	*testProvider
+	instance interface{}
+}
+
+func (p *instanceProvider) Register(Container) NewInstance {
+	return func(...interface{}) (interface{}, error) {
+		return p.instance, nil
+	}
+}
+
+func bindInstance(t *testing.T, c *MyContainer, name string, instance interface{}, depends ...string) {
+	require.NoError(t, c.Bind(&instanceProvider{testProvider: newTestProvider(name, true, depends...), instance: instance}))
+}
+
+func TestContainerShutdownStopsInReverseDependencyOrder(t *testing.T) {
+	c := NewContainer()
+	events, mu := &[]string{}, &sync.Mutex{}
+	closer := &closerService{}
+	bindInstance(t, c, "config", &lifecycleService{name: "config", events: events, mu: mu})
+	bindInstance(t, c, "db", &lifecycleService{name: "db", events: events, mu: mu}, "config")
+	bindInstance(t, c, "app", &lifecycleService{name: "app", events: events, mu: mu}, "db", "file")
+	bindInstance(t, c, "file", closer)
+
+	_, err := c.Make("app")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"start config", "start db", "start app"}, *events)
+
+	require.NoError(t, c.Shutdown(context.Background()))
+	assert.Equal(t, []string{"start config", "start db", "start app", "stop app", "stop db", "stop config"}, *events)
+	assert.True(t, closer.closed)
+
+	_, err = c.Make("app")
+	assert.ErrorIs(t, err, ErrContainerShutdown)
+	assert.NoError(t, c.Shutdown(context.Background()))
+}
+
+func TestContainerRebindStopsReplacedInstanceOnShutdown(t *testing.T) {
+	c := NewContainer()
+	events, mu := &[]string{}, &sync.Mutex{}
+	bindInstance(t, c, "db", &lifecycleService{name: "db-v1", events: events, mu: mu})
+	_, err := c.Make("db")
+	require.NoError(t, err)
+
+	// 被替换的实例不会立即停止，依赖它的服务可能还在使用
+	bindInstance(t, c, "db", &lifecycleService{name: "db-v2", events: events, mu: mu})
+	_, err = c.Make("db")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"start db-v1", "start db-v2"}, *events)
+
+	require.NoError(t, c.Shutdown(context.Background()))
+	assert.Equal(t, []string{"start db-v1", "start db-v2", "stop db-v2", "stop db-v1"}, *events)
+}
+
+func TestContainerStartFailureIsNotCached(t *testing.T) {
+	c := NewContainer()
+	events, mu := &[]string{}, &sync.Mutex{}
+	fail := true
+	svc := &lifecycleService{name: "svc", events: events, mu: mu, startFn: func(ctx context.Context) error {
+		if fail {
+			return errors.New("listen failed")
+		}
+		return nil
+	}}
+	bindInstance(t, c, "svc", svc)
+
+	_, err := c.Make("svc")
+	assert.EqualError(t, err, "contract svc start: listen failed")
+
+	fail = false
+	_, err = c.Make("svc")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"start svc", "start svc"}, *events)
+}
+
+func TestContainerShutdownCancelsStartContext(t *testing.T) {
+	c := NewContainer()
+	var startCtx context.Context
+	bindInstance(t, c, "svc", &lifecycleService{name: "svc", events: &[]string{}, mu: &sync.Mutex{},
+		startFn: func(ctx context.Context) error {
+			startCtx = ctx
+			return nil
+		}})
+	_, err := c.Make("svc")
+	require.NoError(t, err)
+	require.NoError(t, startCtx.Err())
+
+	require.NoError(t, c.Shutdown(context.Background()))
+	assert.ErrorIs(t, startCtx.Err(), context.Canceled)
+}
+
+func TestContainerShutdownAggregatesErrors(t *testing.T) {
+	c := NewContainer()
+	events, mu := &[]string{}, &sync.Mutex{}
+	bindInstance(t, c, "a", &lifecycleService{name: "a", events: events, mu: mu, stopErr: errors.New("a failed")})
+	bindInstance(t, c, "b", &lifecycleService{name: "b", events: events, mu: mu, stopErr: errors.New("b failed")})
+	_, err := c.Make("a")
+	require.NoError(t, err)
+	_, err = c.Make("b")
+	require.NoError(t, err)
+
+	err = c.Shutdown(context.Background())
+	assert.ErrorContains(t, err, "contract a stop: a failed")
+	assert.ErrorContains(t, err, "contract b stop: b failed")
+}
+
+func TestContainerShutdownHonoursDeadline(t *testing.T) {
+	c := NewContainer()
+	events, mu := &[]string{}, &sync.Mutex{}
+	bindInstance(t, c, "a", &lifecycleService{name: "a", events: events, mu: mu})
+	_, err := c.Make("a")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Nanosecond)
+	defer cancel()
+	<-ctx.Done()
+	err = c.Shutdown(ctx)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, []string{"start a"}, *events)
+}
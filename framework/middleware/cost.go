@@ -1,12 +1,15 @@
 package middleware
 
 import (
-	"log"
 	"time"
 
+	"github.com/RZXBxie/web_server/framework/contract"
 	"github.com/RZXBxie/web_server/framework/gin"
 )
 
+// Cost 记录每个请求的耗时，绑定了日志服务时输出到日志服务，否则使用标准库的log，日志中包含请求ID
+//
+// Deprecated: 使用 Metrics 按照路由模板统计请求的耗时，访问日志中已经包含每个请求的耗时
 func Cost() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
@@ -15,7 +18,11 @@ func Cost() gin.HandlerFunc {
 
 		end := time.Now()
 		cost := end.Sub(start)
-		log.Printf("api uri: %v, cost: %v", c.Request.RequestURI, cost)
-
+		logRequest(c, contract.InfoLevel, "request cost", map[string]interface{}{
+			"method": c.Request.Method,
+			"uri":    c.Request.RequestURI,
+			"status": c.Writer.Status(),
+			"cost":   cost,
+		})
 	}
 }
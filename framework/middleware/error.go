@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/RZXBxie/web_server/framework/apperr"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/RZXBxie/web_server/framework/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// ErrorMapper 把领域错误转换为应用错误，不认识err时返回nil
+type ErrorMapper func(err error) *apperr.Error
+
+// MapError 把满足errors.Is(err, target)的错误转换为以err为内部原因的appErr
+func MapError(target error, appErr *apperr.Error) ErrorMapper {
+	return func(err error) *apperr.Error {
+		if errors.Is(err, target) {
+			return appErr.Wrap(err)
+		}
+		return nil
+	}
+}
+
+// ErrorHandler 统一处理请求中的panic和通过 c.Error 记录的错误
+// panic按照500处理，日志中包含调用栈；c.Errors中的错误按照下面的顺序转换为应用错误：
+// err链中的 apperr.Error、按照顺序调用mappers、绑定参数失败按照400处理、其他错误按照500处理
+// handler还没有输出响应内容时，以最后一个错误输出 RFC 7807 格式的响应，5xx的错误不会把内部原因返回给用户
+// 所有的错误都会记录日志，5xx记录为error级别，其他记录为warn级别
+func ErrorHandler(mappers ...ErrorMapper) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			p := recover()
+			if p == nil {
+				return
+			}
+			if p == http.ErrAbortHandler {
+				// 和net/http的约定一致，直接中断连接
+				panic(p)
+			}
+			stack := debug.Stack()
+			// 在gin.Timeout的goroutine中发生的panic，使用发生panic时的调用栈
+			if pe, ok := p.(*gin.PanicError); ok {
+				p, stack = pe.Value, pe.Stack
+			}
+			err, ok := p.(error)
+			if !ok {
+				err = fmt.Errorf("%v", p)
+			}
+			appErr := apperr.ErrInternal.Wrap(fmt.Errorf("panic: %w", err))
+			logError(c, appErr, stack)
+			c.Abort()
+			writeError(c, appErr)
+		}()
+		c.Next()
+
+		if len(c.Errors) == 0 {
+			return
+		}
+		var last *apperr.Error
+		for _, ge := range c.Errors {
+			last = resolveError(ge, mappers)
+			// 超时之后发生的panic记录为错误，带上发生panic时的调用栈
+			var stack []byte
+			var pe *gin.PanicError
+			if errors.As(ge.Err, &pe) {
+				stack = pe.Stack
+			}
+			logError(c, last, stack)
+		}
+		writeError(c, last)
+	}
+}
+
+// resolveError 把gin记录的错误转换为应用错误
+func resolveError(ge *gin.Error, mappers []ErrorMapper) *apperr.Error {
+	if appErr, ok := apperr.From(ge.Err); ok {
+		return appErr
+	}
+	for _, mapper := range mappers {
+		if appErr := mapper(ge.Err); appErr != nil {
+			return appErr
+		}
+	}
+	switch {
+	case ge.IsType(gin.ErrorTypeBind):
+		return apperr.ErrBadRequest.WithMessage("invalid request parameters").Wrap(ge.Err)
+	case ge.IsType(gin.ErrorTypePublic):
+		return apperr.ErrInternal.WithMessage("%v", ge.Err).Wrap(ge.Err)
+	}
+	return apperr.ErrInternal.Wrap(ge.Err)
+}
+
+// writeError 输出错误响应，handler已经输出了响应内容时不再输出，已经输出了状态码时沿用这个状态码
+// 错误码和附加信息作为扩展成员code和details输出，参数校验失败的原因输出在invalid-params中
+func writeError(c *gin.Context, appErr *apperr.Error) {
+	if c.Writer.Size() > 0 {
+		return
+	}
+	status := appErr.Status
+	if c.Writer.Written() {
+		status = c.Writer.Status()
+	}
+	problem := gin.NewProblem(status, appErr.Message).With("code", appErr.Code)
+	if len(appErr.Details) > 0 {
+		problem.With("details", appErr.Details)
+	}
+	var validationErrors validator.ValidationErrors
+	if errors.As(appErr.Cause, &validationErrors) {
+		for _, fe := range validationErrors {
+			reason := "failed on the '" + fe.Tag() + "' rule"
+			if fe.Param() != "" {
+				reason = "failed on the '" + fe.Tag() + "=" + fe.Param() + "' rule"
+			}
+			problem.InvalidParams = append(problem.InvalidParams, gin.InvalidParam{Name: fe.Field(), Reason: reason})
+		}
+	}
+	c.IProblem(problem)
+}
+
+// logError 记录应用错误，5xx记录为error级别，其他记录为warn级别
+func logError(c *gin.Context, appErr *apperr.Error, stack []byte) {
+	fields := map[string]interface{}{
+		"method": c.Request.Method,
+		"uri":    c.Request.RequestURI,
+		"status": appErr.Status,
+		"code":   appErr.Code,
+	}
+	if appErr.Cause != nil {
+		fields["cause"] = appErr.Cause.Error()
+	}
+	if stack != nil {
+		fields["stack"] = string(stack)
+	}
+	level := contract.WarnLevel
+	if appErr.Status >= http.StatusInternalServerError {
+		level = contract.ErrorLevel
+	}
+	logRequest(c, level, appErr.Message, fields)
+}
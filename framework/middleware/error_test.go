@@ -0,0 +1,106 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/RZXBxie/web_server/framework/apperr"
+	"github.com/RZXBxie/web_server/framework/gin"
+	logprovider "github.com/RZXBxie/web_server/framework/provider/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errSubjectMissing = errors.New("subject missing")
+
+func TestErrorHandler(t *testing.T) {
+	router := gin.New()
+	router.Use(RequestID(), ErrorHandler(MapError(errSubjectMissing, apperr.ErrNotFound.WithMessage("subject not found"))))
+	router.GET("/app", func(c *gin.Context) {
+		_ = c.Error(apperr.ErrConflict.WithDetails(map[string]interface{}{"field": "name"}))
+	})
+	router.GET("/mapped", func(c *gin.Context) {
+		_ = c.Error(errSubjectMissing)
+	})
+	router.GET("/bind", func(c *gin.Context) {
+		var form struct {
+			Name string `form:"name" binding:"required"`
+		}
+		_ = c.Bind(&form)
+	})
+	router.GET("/internal", func(c *gin.Context) {
+		_ = c.Error(errors.New("db password is wrong"))
+	})
+	router.GET("/written", func(c *gin.Context) {
+		c.IJson("ok")
+		_ = c.Error(errors.New("after response"))
+	})
+	router.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+
+	cases := []struct {
+		path   string
+		status int
+		body   string
+	}{
+		{"/app", http.StatusConflict, `{"title":"Conflict","detail":"conflict","code":"conflict","details":{"field":"name"}}`},
+		{"/mapped", http.StatusNotFound, `{"title":"Not Found","detail":"subject not found","code":"not_found"}`},
+		{"/internal", http.StatusInternalServerError, `{"title":"Internal Server Error","detail":"internal server error","code":"internal_error"}`},
+		{"/panic", http.StatusInternalServerError, `{"title":"Internal Server Error","detail":"internal server error","code":"internal_error"}`},
+		{"/bind", http.StatusBadRequest, `{"title":"Bad Request","detail":"invalid request parameters","code":"bad_request",
+			"invalid-params":[{"name":"Name","reason":"failed on the 'required' rule"}]}`},
+	}
+	for _, tc := range cases {
+		w := perform(router, tc.path)
+		assert.Equal(t, tc.status, w.Code, tc.path)
+		assert.Equal(t, gin.MIMEProblemJSON, w.Header().Get("Content-Type"), tc.path)
+		var body map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, w.Header().Get(gin.RequestIDHeader), body["request_id"], tc.path)
+		assert.Equal(t, tc.path, body["instance"], tc.path)
+		assert.Equal(t, float64(tc.status), body["status"], tc.path)
+		for _, key := range []string{"request_id", "instance", "status", "type"} {
+			delete(body, key)
+		}
+		actual, _ := json.Marshal(body)
+		assert.JSONEq(t, tc.body, string(actual), tc.path)
+	}
+
+	w := perform(router, "/written")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, `"ok"`, w.Body.String())
+}
+
+func TestErrorHandlerLogs(t *testing.T) {
+	sink := logprovider.NewMemorySink(10)
+	router := gin.New()
+	require.NoError(t, router.Bind(&logprovider.LogProvider{
+		Config:  logprovider.Config{Level: "debug", Formatter: "json"},
+		Writers: []io.Writer{sink},
+	}))
+	router.Use(RequestID(), ErrorHandler())
+	router.GET("/panic", func(c *gin.Context) {
+		panic(errors.New("boom"))
+	})
+	router.GET("/missing", func(c *gin.Context) {
+		_ = c.Error(apperr.ErrNotFound)
+	})
+
+	w := perform(router, "/panic")
+	require.Len(t, sink.Lines(), 1)
+	line := sink.Lines()[0]
+	assert.Contains(t, line, `"level":"error"`)
+	assert.Contains(t, line, `"cause":"panic: boom"`)
+	assert.Contains(t, line, `"request_id":"`+w.Header().Get(gin.RequestIDHeader)+`"`)
+	assert.Contains(t, line, "runtime/debug.Stack")
+
+	perform(router, "/missing")
+	require.Len(t, sink.Lines(), 2)
+	line = sink.Lines()[1]
+	assert.Contains(t, line, `"level":"warn"`)
+	assert.Contains(t, line, `"code":"not_found"`)
+}
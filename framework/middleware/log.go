@@ -0,0 +1,46 @@
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/RZXBxie/web_server/framework/gin"
+)
+
+// logRequest 输出请求中的日志，绑定了日志服务时输出到日志服务，日志服务会带上请求ID
+// 否则使用标准库的log，字段按照key排序输出，最后是请求ID，stack字段单独输出在后面的行中
+func logRequest(c *gin.Context, level contract.LogLevel, msg string, fields map[string]interface{}) {
+	if logger, err := framework.MakeAs[contract.Log](c, contract.LogKey); err == nil {
+		switch level {
+		case contract.DebugLevel:
+			logger.Debug(c, msg, fields)
+		case contract.InfoLevel:
+			logger.Info(c, msg, fields)
+		case contract.WarnLevel:
+			logger.Warn(c, msg, fields)
+		default:
+			logger.Error(c, msg, fields)
+		}
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString(msg)
+	for _, key := range slices.Sorted(maps.Keys(fields)) {
+		if key != "stack" {
+			fmt.Fprintf(&b, ", %s: %v", key, fields[key])
+		}
+	}
+	if id := c.RequestID(); id != "" {
+		fmt.Fprintf(&b, ", request id: %s", id)
+	}
+	log.Print(b.String())
+	if stack, ok := fields["stack"]; ok {
+		log.Printf("%s", stack)
+	}
+}
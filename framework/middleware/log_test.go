@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"testing"
+
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/RZXBxie/web_server/framework/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogRequestFallback(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	flags := log.Flags()
+	log.SetFlags(0)
+	defer func() {
+		log.SetOutput(os.Stderr)
+		log.SetFlags(flags)
+	}()
+
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/", func(c *gin.Context) {
+		c.SetRequestID("req-1")
+		logRequest(c, contract.WarnLevel, "something failed", map[string]interface{}{
+			"uri":   "/",
+			"code":  "bad_request",
+			"stack": "goroutine 1",
+		})
+	})
+	perform(router, "/")
+	assert.Equal(t, "something failed, code: bad_request, uri: /, request id: req-1\ngoroutine 1\n", buf.String())
+}
@@ -0,0 +1,92 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/RZXBxie/web_server/framework/gin"
+)
+
+// unmatchedRoute 没有匹配到路由的请求使用的route标签，避免原始的请求路径让指标无限增长
+const unmatchedRoute = "unmatched"
+
+// otherMethod 非标准的请求方法使用的method标签，避免客户端任意的请求方法让指标无限增长
+const otherMethod = "other"
+
+// sizeBuckets 请求和响应大小的直方图的桶，单位为字节
+var sizeBuckets = []float64{100, 1000, 10000, 100000, 1e6, 1e7}
+
+// httpMetrics 是Metrics中间件使用的指标
+type httpMetrics struct {
+	inFlight     contract.Gauge
+	requests     contract.CounterVec
+	duration     contract.HistogramVec
+	requestSize  contract.HistogramVec
+	responseSize contract.HistogramVec
+}
+
+// newHTTPMetrics 在指标服务中注册Metrics中间件使用的指标
+func newHTTPMetrics(metrics contract.Metrics) *httpMetrics {
+	return &httpMetrics{
+		inFlight: metrics.Gauge("http_requests_in_flight", "Number of HTTP requests currently being served.").With(),
+		requests: metrics.Counter("http_requests_total", "Total number of HTTP requests.", "method", "route", "status"),
+		duration: metrics.Histogram("http_request_duration_seconds", "HTTP request latency in seconds.", nil, "method", "route"),
+		requestSize: metrics.Histogram("http_request_size_bytes", "HTTP request body size in bytes.", sizeBuckets,
+			"method", "route"),
+		responseSize: metrics.Histogram("http_response_size_bytes", "HTTP response body size in bytes.", sizeBuckets,
+			"method", "route"),
+	}
+}
+
+// methodLabel 标准的请求方法原样作为标签，其他的请求方法都为other
+func methodLabel(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+		http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace:
+		return method
+	}
+	return otherMethod
+}
+
+// Metrics 记录请求的数量、耗时、请求和响应的大小以及正在处理的请求数，需要绑定 metrics.MetricsProvider
+// 标签使用路由模板(c.FullPath)而不是原始的请求路径，例如/subject/:id，没有匹配到路由时为unmatched
+// 非标准的请求方法的method标签为other
+// 指标在每个指标服务的第一个请求中注册，之后的请求直接使用
+func Metrics() gin.HandlerFunc {
+	// 路由组可以使用不同的服务容器，按照指标服务缓存注册的指标
+	var registered sync.Map
+	return func(c *gin.Context) {
+		metrics, err := framework.MakeAs[contract.Metrics](c, contract.MetricsKey)
+		if err != nil {
+			c.Next()
+			return
+		}
+		cached, ok := registered.Load(metrics)
+		if !ok {
+			cached, _ = registered.LoadOrStore(metrics, newHTTPMetrics(metrics))
+		}
+		m := cached.(*httpMetrics)
+		m.inFlight.Inc()
+		defer m.inFlight.Dec()
+
+		start := time.Now()
+		c.Next()
+		cost := time.Since(start)
+
+		route := c.FullPath()
+		if route == "" {
+			route = unmatchedRoute
+		}
+		method := methodLabel(c.Request.Method)
+		m.requests.With(method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		m.duration.With(method, route).Observe(cost.Seconds())
+		if c.Request.ContentLength >= 0 {
+			m.requestSize.With(method, route).Observe(float64(c.Request.ContentLength))
+		}
+		m.responseSize.With(method, route).Observe(float64(max(c.Writer.Size(), 0)))
+	}
+}
@@ -0,0 +1,44 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RZXBxie/web_server/framework/gin"
+	"github.com/RZXBxie/web_server/framework/provider/metrics"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMetrics(t *testing.T) {
+	router := gin.New()
+	require.NoError(t, router.Bind(&metrics.MetricsProvider{Config: metrics.Config{DisableRuntime: true}}))
+	router.Use(Metrics())
+	metrics.RegisterRoutes(router)
+	router.GET("/subject/:id", func(c *gin.Context) {
+		c.IJson("subject")
+	})
+
+	for _, path := range []string{"/subject/1", "/subject/2", "/missing/1", "/missing/2"} {
+		perform(router, path)
+	}
+	for _, method := range []string{"PURGE", "FOO"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(method, "/subject/1", nil))
+	}
+	w := perform(router, "/metrics")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, metrics.ContentType, w.Header().Get("Content-Type"))
+
+	out := w.Body.String()
+	assert.Contains(t, out, `http_requests_total{method="GET",route="/subject/:id",status="200"} 2`)
+	assert.Contains(t, out, `http_requests_total{method="GET",route="unmatched",status="404"} 2`)
+	assert.Contains(t, out, `http_requests_total{method="other",route="unmatched",status="404"} 2`)
+	assert.NotContains(t, out, "PURGE")
+	assert.Contains(t, out, `http_request_duration_seconds_count{method="GET",route="/subject/:id"} 2`)
+	assert.Contains(t, out, `http_response_size_bytes_sum{method="GET",route="/subject/:id"} 18`)
+	// 抓取指标的请求还在处理中
+	assert.Contains(t, out, "http_requests_in_flight 1\n")
+	assert.NotContains(t, out, "/subject/1")
+	assert.NotContains(t, out, "go_goroutines")
+}
@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/apperr"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/RZXBxie/web_server/framework/gin"
+)
+
+// 限流相关的响应头，RateLimit-* 遵循IETF的RateLimit header fields草案
+const (
+	RateLimitLimitHeader     = "RateLimit-Limit"
+	RateLimitRemainingHeader = "RateLimit-Remaining"
+	RateLimitResetHeader     = "RateLimit-Reset"
+	RateLimitPolicyHeader    = "RateLimit-Policy"
+	RetryAfterHeader         = "Retry-After"
+)
+
+// KeyFunc 返回请求的限流key，返回空字符串时这个请求不限流
+type KeyFunc func(c *gin.Context) string
+
+// KeyByIP 按照客户端IP限流，只有来自可信代理的请求才会使用X-Forwarded-For等请求头中的IP
+// 部署在代理后面时需要通过 Engine.SetTrustedProxies 设置可信代理
+func KeyByIP(c *gin.Context) string {
+	return c.ClientIP()
+}
+
+// KeyByHeader 按照请求头中的值限流，例如API key，没有这个请求头的请求不限流
+func KeyByHeader(name string) KeyFunc {
+	return func(c *gin.Context) string {
+		return c.GetHeader(name)
+	}
+}
+
+// KeyByUser 按照鉴权中间件通过 c.Set(key, userID) 保存的用户ID限流，没有登录的请求不限流
+func KeyByUser(key string) KeyFunc {
+	return func(c *gin.Context) string {
+		return c.GetString(key)
+	}
+}
+
+// KeyByRoute 按照路由模板限流，同一个路由的所有请求共享配额，例如 GET /subject/:id
+func KeyByRoute(c *gin.Context) string {
+	route := c.FullPath()
+	if route == "" {
+		route = unmatchedRoute
+	}
+	return c.Request.Method + " " + route
+}
+
+// RateLimitConfig 限流中间件的配置
+type RateLimitConfig struct {
+	// Name 区分不同的限流规则，同一个key在不同的规则中分别计数，为空时自动生成
+	Name string
+	// Policy 默认的限流策略
+	Policy contract.RateLimitPolicy
+	// Policies 按照key覆盖默认的限流策略，例如给某个API key更高的配额
+	Policies map[string]contract.RateLimitPolicy
+	// Key 返回请求的限流key，为空时使用KeyByIP
+	Key KeyFunc
+	// Store 保存限流状态的存储，为空时使用服务容器中绑定的 ratelimit.RateLimitProvider
+	Store contract.RateLimitStore
+}
+
+// rateLimitSeq 为没有名字的限流规则生成名字
+var rateLimitSeq atomic.Int64
+
+// RateLimit 按照配置对请求限流，可以在路由组上使用，每个路由组使用自己的规则
+// 每个响应都会带上RateLimit-*响应头，超过配额时以 RFC 7807 格式返回429，并通过Retry-After告知需要等待的秒数
+// 存储出错时不限流，并记录日志，避免存储的故障导致所有的请求失败
+// 策略不合法时panic
+func RateLimit(cfg RateLimitConfig) gin.HandlerFunc {
+	if err := cfg.Policy.Validate(); err != nil {
+		panic(err)
+	}
+	for key, policy := range cfg.Policies {
+		if err := policy.Validate(); err != nil {
+			panic(fmt.Errorf("%w for key %q", err, key))
+		}
+	}
+	if cfg.Name == "" {
+		cfg.Name = "ratelimit-" + strconv.FormatInt(rateLimitSeq.Add(1), 10)
+	}
+	if cfg.Key == nil {
+		cfg.Key = KeyByIP
+	}
+	return func(c *gin.Context) {
+		key := cfg.Key(c)
+		if key == "" {
+			c.Next()
+			return
+		}
+		policy, ok := cfg.Policies[key]
+		if !ok {
+			policy = cfg.Policy
+		}
+		store := cfg.Store
+		if store == nil {
+			var err error
+			if store, err = framework.MakeAs[contract.RateLimitStore](c, contract.RateLimitKey); err != nil {
+				logRateLimitError(c, cfg.Name, err)
+				c.Next()
+				return
+			}
+		}
+		result, err := store.Allow(c, cfg.Name+":"+key, policy, time.Now())
+		if err != nil {
+			logRateLimitError(c, cfg.Name, err)
+			c.Next()
+			return
+		}
+
+		c.ISetHeader(RateLimitLimitHeader, strconv.Itoa(result.Limit)).
+			ISetHeader(RateLimitRemainingHeader, strconv.Itoa(result.Remaining)).
+			ISetHeader(RateLimitResetHeader, strconv.FormatInt(seconds(result.Reset), 10)).
+			ISetHeader(RateLimitPolicyHeader, strconv.Itoa(policy.Limit)+";w="+strconv.FormatInt(seconds(policy.Window), 10))
+		if !result.Allowed {
+			c.Abort()
+			c.ISetHeader(RetryAfterHeader, strconv.FormatInt(max(seconds(result.RetryAfter), 1), 10)).
+				IProblem(gin.NewProblem(apperr.ErrTooManyRequests.Status, apperr.ErrTooManyRequests.Message).
+					With("code", apperr.ErrTooManyRequests.Code))
+			return
+		}
+		c.Next()
+	}
+}
+
+// seconds 向上取整为秒
+func seconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
+
+// logRateLimitError 记录限流存储的错误，这时请求不限流
+func logRateLimitError(c *gin.Context, name string, err error) {
+	logRequest(c, contract.ErrorLevel, "rate limit store failed, request is not limited", map[string]interface{}{
+		"ratelimit": name,
+		"uri":       c.Request.RequestURI,
+		"error":     err.Error(),
+	})
+}
@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/RZXBxie/web_server/framework/gin"
+	"github.com/RZXBxie/web_server/framework/provider/ratelimit"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func performFrom(router *gin.Engine, path string, header http.Header) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestRateLimit(t *testing.T) {
+	router := gin.New()
+	require.NoError(t, router.Bind(&ratelimit.RateLimitProvider{}))
+	router.Use(RateLimit(RateLimitConfig{Policy: contract.RateLimitPolicy{Limit: 2, Window: time.Minute}}))
+	router.GET("/", func(c *gin.Context) {
+		c.IJson("ok")
+	})
+
+	w := perform(router, "/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "2", w.Header().Get(RateLimitLimitHeader))
+	assert.Equal(t, "1", w.Header().Get(RateLimitRemainingHeader))
+	assert.Equal(t, "30", w.Header().Get(RateLimitResetHeader))
+	assert.Equal(t, "2;w=60", w.Header().Get(RateLimitPolicyHeader))
+
+	w = perform(router, "/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "0", w.Header().Get(RateLimitRemainingHeader))
+
+	w = perform(router, "/")
+	assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	assert.Equal(t, gin.MIMEProblemJSON, w.Header().Get("Content-Type"))
+	assert.Equal(t, "30", w.Header().Get(RetryAfterHeader))
+	assert.Contains(t, w.Body.String(), `"code":"too_many_requests"`)
+}
+
+func TestRateLimitClientIP(t *testing.T) {
+	router := gin.New()
+	require.NoError(t, router.SetTrustedProxies([]string{"192.0.2.1"}))
+	router.Use(RateLimit(RateLimitConfig{
+		Policy: contract.RateLimitPolicy{Algorithm: contract.SlidingWindow, Limit: 1, Window: time.Minute},
+		Store:  ratelimit.NewMemoryStore(),
+	}))
+	router.GET("/", func(c *gin.Context) {
+		c.IJson("ok")
+	})
+
+	// httptest的请求来自192.0.2.1，是可信代理，使用X-Forwarded-For中的IP
+	assert.Equal(t, http.StatusOK, performFrom(router, "/", http.Header{"X-Forwarded-For": {"203.0.113.1"}}).Code)
+	assert.Equal(t, http.StatusOK, performFrom(router, "/", http.Header{"X-Forwarded-For": {"203.0.113.2"}}).Code)
+	assert.Equal(t, http.StatusTooManyRequests, performFrom(router, "/", http.Header{"X-Forwarded-For": {"203.0.113.1"}}).Code)
+
+	// 不可信的来源伪造X-Forwarded-For不能绕过限流
+	require.NoError(t, router.SetTrustedProxies(nil))
+	assert.Equal(t, http.StatusOK, performFrom(router, "/", http.Header{"X-Forwarded-For": {"203.0.113.3"}}).Code)
+	assert.Equal(t, http.StatusTooManyRequests, performFrom(router, "/", http.Header{"X-Forwarded-For": {"203.0.113.4"}}).Code)
+}
+
+func TestRateLimitPerKeyPolicy(t *testing.T) {
+	router := gin.New()
+	router.Use(RateLimit(RateLimitConfig{
+		Policy:   contract.RateLimitPolicy{Limit: 1, Window: time.Minute},
+		Policies: map[string]contract.RateLimitPolicy{"premium": {Limit: 3, Window: time.Minute}},
+		Key:      KeyByHeader("X-API-Key"),
+		Store:    ratelimit.NewMemoryStore(),
+	}))
+	router.GET("/", func(c *gin.Context) {
+		c.IJson("ok")
+	})
+
+	basic := http.Header{"X-Api-Key": {"basic"}}
+	premium := http.Header{"X-Api-Key": {"premium"}}
+	assert.Equal(t, http.StatusOK, performFrom(router, "/", basic).Code)
+	assert.Equal(t, http.StatusTooManyRequests, performFrom(router, "/", basic).Code)
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, performFrom(router, "/", premium).Code)
+	}
+	assert.Equal(t, http.StatusTooManyRequests, performFrom(router, "/", premium).Code)
+
+	// 没有API key的请求不限流
+	w := perform(router, "/")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Empty(t, w.Header().Get(RateLimitLimitHeader))
+}
+
+func TestRateLimitGroups(t *testing.T) {
+	router := gin.New()
+	require.NoError(t, router.Bind(&ratelimit.RateLimitProvider{}))
+	router.Use(func(c *gin.Context) {
+		c.Set("user", c.Query("user"))
+	})
+	handler := func(c *gin.Context) {
+		c.IJson("ok")
+	}
+	limited := router.Group("/limited", RateLimit(RateLimitConfig{
+		Policy: contract.RateLimitPolicy{Limit: 1, Window: time.Minute},
+		Key:    KeyByUser("user"),
+	}))
+	limited.GET("/a", handler)
+	limited.GET("/b", handler)
+	perRoute := router.Group("/route", RateLimit(RateLimitConfig{
+		Policy: contract.RateLimitPolicy{Limit: 1, Window: time.Minute},
+		Key:    KeyByRoute,
+	}))
+	perRoute.GET("/a", handler)
+	perRoute.GET("/b", handler)
+	router.GET("/open", handler)
+
+	// 同一个用户在路由组中共享配额，不同的用户分别计数
+	assert.Equal(t, http.StatusOK, perform(router, "/limited/a?user=1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, perform(router, "/limited/b?user=1").Code)
+	assert.Equal(t, http.StatusOK, perform(router, "/limited/b?user=2").Code)
+
+	// 按照路由限流时每个路由分别计数
+	assert.Equal(t, http.StatusOK, perform(router, "/route/a?user=1").Code)
+	assert.Equal(t, http.StatusOK, perform(router, "/route/b?user=1").Code)
+	assert.Equal(t, http.StatusTooManyRequests, perform(router, "/route/a?user=2").Code)
+
+	// 路由组之外的路由不限流
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, perform(router, "/open?user=1").Code)
+	}
+}
+
+// brokenStore 模拟不可用的共享存储
+type brokenStore struct{}
+
+func (brokenStore) Allow(context.Context, string, contract.RateLimitPolicy, time.Time) (contract.RateLimitResult, error) {
+	return contract.RateLimitResult{}, errors.New("connection refused")
+}
+
+func TestRateLimitFailOpen(t *testing.T) {
+	router := gin.New()
+	router.Use(RateLimit(RateLimitConfig{
+		Policy: contract.RateLimitPolicy{Limit: 1, Window: time.Minute},
+		Store:  brokenStore{},
+	}))
+	router.GET("/", func(c *gin.Context) {
+		c.IJson("ok")
+	})
+	for i := 0; i < 3; i++ {
+		assert.Equal(t, http.StatusOK, perform(router, "/").Code)
+	}
+}
+
+func TestRateLimitInvalidPolicy(t *testing.T) {
+	assert.Panics(t, func() {
+		RateLimit(RateLimitConfig{Policy: contract.RateLimitPolicy{Limit: 1}})
+	})
+	assert.Panics(t, func() {
+		RateLimit(RateLimitConfig{
+			Policy:   contract.RateLimitPolicy{Limit: 1, Window: time.Second},
+			Policies: map[string]contract.RateLimitPolicy{"a": {Limit: -1, Window: time.Second}},
+		})
+	})
+}
@@ -1,19 +1,11 @@
 package middleware
 
 import (
-	"net/http"
-
 	"github.com/RZXBxie/web_server/framework/gin"
 )
 
+// Recovery 捕获请求处理中的panic，返回500并记录带有调用栈和请求ID的日志
+// 它是没有注册任何 ErrorMapper 的 ErrorHandler，同样会处理 c.Error 记录的错误
 func Recovery() gin.HandlerFunc {
-	return func(c *gin.Context) {
-		defer func() {
-			if err := recover(); err != nil {
-				c.ISetStatus(http.StatusInternalServerError).IJson("internal server error")
-
-			}
-		}()
-		c.Next()
-	}
+	return ErrorHandler()
 }
@@ -0,0 +1,60 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/RZXBxie/web_server/framework/gin"
+)
+
+// maxRequestIDLength 接受的请求ID的最大长度
+const maxRequestIDLength = 128
+
+// RequestID 为每个请求设置请求ID，需要放在其他中间件之前
+// 请求头 X-Request-ID 合法时沿用上游传递的请求ID，否则生成一个新的请求ID
+// 请求ID保存在gin.Context和请求的context.Context中，并写入响应头，日志服务和gin的Logger会自动输出它
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(gin.RequestIDHeader)
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		c.SetRequestID(id)
+		c.Next()
+	}
+}
+
+// validRequestID 请求ID只能包含字母、数字以及 - _ . : 并且不能超过最大长度，避免日志注入
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// newRequestID 生成一个随机的UUID v4格式的请求ID
+func newRequestID() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	var buf [36]byte
+	hex.Encode(buf[0:8], b[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], b[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], b[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], b[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], b[10:])
+	return string(buf[:])
+}
@@ -0,0 +1,42 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RZXBxie/web_server/framework/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequestID(t *testing.T) {
+	router := gin.New()
+	router.Use(RequestID())
+	router.GET("/", func(c *gin.Context) {
+		c.ISetOkStatus().IText("%s", c.RequestID())
+	})
+	get := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if id != "" {
+			req.Header.Set(gin.RequestIDHeader, id)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	// 合法的请求ID沿用上游传递的值
+	w := get("upstream-id_1.2:3")
+	assert.Equal(t, "upstream-id_1.2:3", w.Header().Get(gin.RequestIDHeader))
+	assert.Equal(t, "upstream-id_1.2:3", w.Body.String())
+
+	// 缺失或者不合法时生成新的请求ID
+	for _, id := range []string{"", "bad id", "evil\nline", strings.Repeat("a", 129)} {
+		w := get(id)
+		generated := w.Header().Get(gin.RequestIDHeader)
+		assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, generated)
+		assert.Equal(t, generated, w.Body.String())
+	}
+	assert.NotEqual(t, get("").Body.String(), get("").Body.String())
+}
@@ -1,42 +1,29 @@
 package middleware
 
 import (
-	"context"
-	"fmt"
-	"net/http"
 	"time"
 
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
 	"github.com/RZXBxie/web_server/framework/gin"
 )
 
+// Timeout 限制后续handler的处理时间，超时时返回504，详见 gin.Timeout
+// 路由组的超时时间也可以使用 RouterGroup.WithTimeout 声明，单个路由的超时时间使用 RouterGroup.Timeout 声明
 func Timeout(d time.Duration) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		finishChan := make(chan struct{}, 1)
-		panicChan := make(chan interface{}, 1)
-
-		durationCtx, cancel := context.WithTimeout(c.Copy(), d)
-		defer cancel() // 确保释放资源
-
-		go func() {
-			defer func() {
-				if err := recover(); err != nil {
-					panicChan <- err
-				}
-			}()
-
-			c.Next()
-			finishChan <- struct{}{}
-		}()
+	return gin.Timeout(d)
+}
 
-		select {
-		case <-finishChan:
-			fmt.Println("finish: request completed successfully")
-		case p := <-panicChan:
-			c.Abort()
-			c.ISetStatus(http.StatusInternalServerError).IJson(gin.H{"error": "internal server error", "detail": fmt.Sprint(p)})
-		case <-durationCtx.Done():
-			c.Abort()
-			c.ISetStatus(http.StatusGatewayTimeout).IJson(gin.H{"error": "request timeout"})
+// ConfigTimeout 和 Timeout 相同，只是超时时间在每个请求中从配置服务的key读取
+// 配置服务重新加载之后新的超时时间立即生效，配置项不存在或者不是正数时使用def
+func ConfigTimeout(key string, def time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		d := def
+		if configService, err := framework.MakeAs[contract.Config](c, contract.ConfigKey); err == nil {
+			if v := configService.GetDuration(key); v > 0 {
+				d = v
+			}
 		}
+		gin.Timeout(d)(c)
 	}
 }
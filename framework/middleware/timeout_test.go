@@ -0,0 +1,128 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework/gin"
+	logprovider "github.com/RZXBxie/web_server/framework/provider/log"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func perform(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestTimeoutFinishesInTime(t *testing.T) {
+	router := gin.New()
+	router.Use(Timeout(time.Second))
+	router.GET("/", func(c *gin.Context) {
+		c.ISetHeader("X-Handler", "yes")
+		c.ISetStatus(http.StatusCreated).IJson("created")
+	})
+
+	w := perform(router, "/")
+	assert.Equal(t, http.StatusCreated, w.Code)
+	assert.Equal(t, "yes", w.Header().Get("X-Handler"))
+	assert.Equal(t, `"created"`, w.Body.String())
+}
+
+func TestTimeoutCancelsHandler(t *testing.T) {
+	var status int
+	var lateErr, ctxErr error
+	router := gin.New()
+	router.Use(RequestID(), func(c *gin.Context) {
+		c.Next()
+		// Timeout返回的时候handler已经结束，外层的中间件看到的是504
+		status = c.Writer.Status()
+	}, Timeout(20*time.Millisecond))
+	router.GET("/", func(c *gin.Context) {
+		c.ISetHeader("X-Handler", "yes")
+		<-c.Request.Context().Done()
+		ctxErr = c.Request.Context().Err()
+		_, lateErr = c.Writer.Write([]byte("too late"))
+	})
+
+	w := perform(router, "/")
+	assert.Equal(t, http.StatusGatewayTimeout, w.Code)
+	assert.Equal(t, http.StatusGatewayTimeout, status)
+	assert.Empty(t, w.Header().Get("X-Handler"))
+	assert.Equal(t, gin.MIMEProblemJSON, w.Header().Get("Content-Type"))
+	var body map[string]interface{}
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+	assert.Equal(t, float64(http.StatusGatewayTimeout), body["status"])
+	assert.Equal(t, "request did not complete within 20ms", body["detail"])
+	assert.Equal(t, w.Header().Get(gin.RequestIDHeader), body["request_id"])
+	assert.ErrorIs(t, ctxErr, context.DeadlineExceeded)
+	assert.ErrorIs(t, lateErr, http.ErrHandlerTimeout)
+}
+
+func TestTimeoutPanicIsRecovered(t *testing.T) {
+	router := gin.New()
+	router.Use(Recovery(), Timeout(time.Second))
+	router.GET("/", func(c *gin.Context) {
+		c.IJson("partial")
+		panic("boom")
+	})
+
+	w := perform(router, "/")
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.JSONEq(t, `{"type":"about:blank","title":"Internal Server Error","status":500,"detail":"internal server error","instance":"/","code":"internal_error"}`, w.Body.String())
+}
+
+// panicInHandler 用于在调用栈中查找发生panic的位置
+func panicInHandler(c *gin.Context) {
+	panic("boom")
+}
+
+func TestTimeoutPanicKeepsStack(t *testing.T) {
+	sink := logprovider.NewMemorySink(10)
+	router := gin.New()
+	require.NoError(t, router.Bind(&logprovider.LogProvider{
+		Config:  logprovider.Config{Level: "debug", Formatter: "json"},
+		Writers: []io.Writer{sink},
+	}))
+	router.Use(ErrorHandler(), Timeout(time.Second))
+	router.GET("/", panicInHandler)
+
+	assert.Equal(t, http.StatusInternalServerError, perform(router, "/").Code)
+	require.Len(t, sink.Lines(), 1)
+	assert.Contains(t, sink.Lines()[0], `"cause":"panic: boom"`)
+	assert.Contains(t, sink.Lines()[0], "panicInHandler")
+}
+
+func TestTimeoutConcurrentRequests(t *testing.T) {
+	router := gin.New()
+	router.Use(Timeout(100 * time.Millisecond))
+	router.GET("/fast", func(c *gin.Context) {
+		c.IJson("ok")
+	})
+	router.GET("/slow", func(c *gin.Context) {
+		<-c.Request.Context().Done()
+		c.IJson("cancelled")
+	})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if i%2 == 0 {
+				assert.Equal(t, http.StatusOK, perform(router, "/fast").Code)
+			} else {
+				w := perform(router, "/slow")
+				assert.Equal(t, http.StatusGatewayTimeout, w.Code, w.Body.String())
+			}
+		}()
+	}
+	wg.Wait()
+}
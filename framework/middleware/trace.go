@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/RZXBxie/web_server/framework/gin"
+)
+
+// Trace 为每个请求创建一个server span，需要绑定 trace.TraceProvider
+// 请求头中有合法的traceparent时，span作为上游span的子span，否则开始新的调用链路
+// span以请求方法和路由模板命名，例如 GET /subject/:id，handler中可以用 c 作为父span创建子span
+// 5xx的响应把span标记为失败，并记录c.Errors中的错误
+func Trace() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tracer, err := framework.MakeAs[contract.Trace](c, contract.TraceKey)
+		if err != nil {
+			c.Next()
+			return
+		}
+		route := c.FullPath()
+		name := c.Request.Method + " " + route
+		if route == "" {
+			name = c.Request.Method + " " + unmatchedRoute
+		}
+		ctx := tracer.Extract(c.Request.Context(), c.Request.Header)
+		ctx, span := tracer.Start(ctx, name, contract.SpanKindServer)
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+
+		attrs := map[string]interface{}{
+			"http.method": c.Request.Method,
+			"http.target": c.Request.URL.Path,
+		}
+		if route != "" {
+			attrs["http.route"] = route
+		}
+		if id := c.RequestID(); id != "" {
+			attrs["http.request_id"] = id
+		}
+		span.SetAttributes(attrs)
+
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(map[string]interface{}{"http.status_code": status})
+		if status >= http.StatusInternalServerError {
+			for _, e := range c.Errors {
+				span.RecordError(e.Err)
+			}
+			span.SetStatus(contract.SpanStatusError, fmt.Sprintf("%d %s", status, http.StatusText(status)))
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/RZXBxie/web_server/framework/gin"
+	"github.com/RZXBxie/web_server/framework/provider/trace"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrace(t *testing.T) {
+	exporter := trace.NewMemoryExporter()
+	router := gin.New()
+	require.NoError(t, router.Bind(&trace.TraceProvider{Exporter: exporter}))
+	tracer := framework.MustMakeAs[contract.Trace](router.Container(), contract.TraceKey)
+	router.Use(RequestID(), Trace(), ErrorHandler())
+	router.GET("/subject/:id", func(c *gin.Context) {
+		_, span := tracer.Start(c, "load subject", contract.SpanKindInternal)
+		span.End()
+		c.IJson("subject")
+	})
+	router.GET("/broken", func(c *gin.Context) {
+		_ = c.Error(errors.New("db is down"))
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/subject/1", nil)
+	req.Header.Set(contract.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	w := perform(router, "/broken")
+	perform(router, "/missing")
+
+	require.NoError(t, tracer.Flush(context.Background()))
+	spans := exporter.Spans()
+	require.Len(t, spans, 4)
+
+	child, server := spans[0], spans[1]
+	assert.Equal(t, "load subject", child.Name)
+	assert.Equal(t, server.SpanID, child.ParentSpanID)
+	assert.Equal(t, "GET /subject/:id", server.Name)
+	assert.Equal(t, contract.SpanKindServer, server.Kind)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", server.ParentSpanID)
+	assert.Equal(t, "/subject/:id", server.Attributes["http.route"])
+	assert.Equal(t, "/subject/1", server.Attributes["http.target"])
+	assert.Equal(t, http.StatusOK, server.Attributes["http.status_code"])
+	assert.Equal(t, contract.SpanStatusUnset, server.Status)
+
+	broken := spans[2]
+	assert.Equal(t, "GET /broken", broken.Name)
+	assert.Empty(t, broken.ParentSpanID)
+	assert.Equal(t, w.Header().Get(gin.RequestIDHeader), broken.Attributes["http.request_id"])
+	assert.Equal(t, contract.SpanStatusError, broken.Status)
+	require.Len(t, broken.Events, 1)
+	assert.Equal(t, "db is down", broken.Events[0].Attributes["exception.message"])
+
+	assert.Equal(t, "GET unmatched", spans[3].Name)
+	assert.Equal(t, http.StatusNotFound, spans[3].Attributes["http.status_code"])
+}
@@ -24,3 +24,56 @@ type ServiceProvider interface {
 	// 如果 Boot 返回error，整个服务实例化就会实例化失败
 	Boot(Container) error
 }
+
+// ServiceDepender 是服务提供者可以选择实现的接口，用于声明它依赖的其他服务
+// 容器会保证依赖的服务先于当前服务实例化，依赖未绑定时拒绝实例化当前服务
+type ServiceDepender interface {
+	// Depends 返回依赖的服务的关键字凭证
+	Depends() []string
+}
+
+// providerDepends 获取服务提供者声明的依赖，没有实现ServiceDepender的服务提供者没有依赖
+func providerDepends(sp ServiceProvider) []string {
+	if d, ok := sp.(ServiceDepender); ok {
+		return d.Depends()
+	}
+	return nil
+}
+
+// Lifetime 定义了服务实例的生命周期
+type Lifetime int
+
+const (
+	// Singleton 整个容器共享一个实例，这是默认的生命周期
+	Singleton Lifetime = iota
+	// Scoped 每个作用域一个实例，一个请求就是一个作用域，作用域结束时实例会被停止
+	Scoped
+	// Transient 每次make都创建一个新的实例，实例不由容器管理
+	Transient
+)
+
+func (l Lifetime) String() string {
+	switch l {
+	case Singleton:
+		return "singleton"
+	case Scoped:
+		return "scoped"
+	case Transient:
+		return "transient"
+	}
+	return "unknown"
+}
+
+// ServiceLifetime 是服务提供者可以选择实现的接口，用于声明服务实例的生命周期
+// 没有实现这个接口的服务提供者提供的是单例
+type ServiceLifetime interface {
+	Lifetime() Lifetime
+}
+
+// providerLifetime 获取服务提供者声明的生命周期
+func providerLifetime(sp ServiceProvider) Lifetime {
+	if l, ok := sp.(ServiceLifetime); ok {
+		return l.Lifetime()
+	}
+	return Singleton
+}
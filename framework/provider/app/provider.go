@@ -0,0 +1,48 @@
+package app
+
+import (
+	"reflect"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+)
+
+// AppProvider 提供应用环境服务
+type AppProvider struct {
+	// BaseFolder 应用的根目录，为空时使用当前工作目录
+	BaseFolder string
+	// EnvName 决定运行环境的环境变量名，例如 APP_ENV
+	EnvName string
+	// Args 命令行参数，支持 --env 和 --base-folder
+	Args []string
+}
+
+// Name 将服务对应的字符串凭证返回
+func (sp *AppProvider) Name() string {
+	return contract.AppKey
+}
+
+// Contract 声明服务实例需要实现contract.App接口
+func (sp *AppProvider) Contract() reflect.Type {
+	return framework.ContractOf[contract.App]()
+}
+
+// Register 注册应用环境服务的实例化方法
+func (sp *AppProvider) Register(c framework.Container) framework.NewInstance {
+	return NewAppService
+}
+
+// Boot 应用环境服务不需要准备工作
+func (sp *AppProvider) Boot(c framework.Container) error {
+	return nil
+}
+
+// Params 返回服务容器、根目录、环境变量名和命令行参数
+func (sp *AppProvider) Params(c framework.Container) []interface{} {
+	return []interface{}{c, sp.BaseFolder, sp.EnvName, sp.Args}
+}
+
+// IsDefer 运行环境决定了gin的模式和配置目录，需要在绑定的时候就确定
+func (sp *AppProvider) IsDefer() bool {
+	return false
+}
@@ -0,0 +1,138 @@
+package app
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/RZXBxie/web_server/framework/gin"
+)
+
+// AppService 是应用环境服务的实现
+type AppService struct {
+	contract.App
+
+	// c 服务容器
+	c framework.Container
+
+	// environment 应用运行的环境
+	environment string
+
+	// baseFolder 应用的根目录
+	baseFolder string
+}
+
+// envAliases 环境名称的简写
+var envAliases = map[string]string{
+	"dev":         contract.EnvDevelopment,
+	"development": contract.EnvDevelopment,
+	"test":        contract.EnvTesting,
+	"testing":     contract.EnvTesting,
+	"prod":        contract.EnvProduction,
+	"production":  contract.EnvProduction,
+}
+
+// ginModes 每个环境对应的gin模式
+var ginModes = map[string]string{
+	contract.EnvDevelopment: gin.DebugMode,
+	contract.EnvTesting:     gin.TestMode,
+	contract.EnvProduction:  gin.ReleaseMode,
+}
+
+// NewAppService 初始化应用环境服务，参数为服务容器、根目录、环境变量名和命令行参数
+// 环境的优先级为：命令行参数 --env、环境变量、默认的开发环境
+// 根目录的优先级为：命令行参数 --base-folder、参数指定的根目录、当前工作目录
+// 确定环境之后会把gin切换到对应的模式
+func NewAppService(params ...interface{}) (interface{}, error) {
+	c := params[0].(framework.Container)
+	baseFolder := params[1].(string)
+	envName := params[2].(string)
+	args := params[3].([]string)
+
+	env := contract.EnvDevelopment
+	if envName != "" && os.Getenv(envName) != "" {
+		env = os.Getenv(envName)
+	}
+	if v, ok := argValue(args, "env"); ok {
+		env = v
+	}
+	environment, ok := envAliases[strings.ToLower(env)]
+	if !ok {
+		return nil, fmt.Errorf("app: unknown environment %q", env)
+	}
+
+	if v, ok := argValue(args, "base-folder"); ok {
+		baseFolder = v
+	}
+	if baseFolder == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return nil, err
+		}
+		baseFolder = wd
+	}
+	baseFolder, err := filepath.Abs(baseFolder)
+	if err != nil {
+		return nil, err
+	}
+
+	gin.SetMode(ginModes[environment])
+	return &AppService{c: c, environment: environment, baseFolder: baseFolder}, nil
+}
+
+// argValue 从命令行参数中查找 --name=value 或者 --name value 形式的参数
+func argValue(args []string, name string) (string, bool) {
+	flag := "--" + name
+	for i, arg := range args {
+		if v, ok := strings.CutPrefix(arg, flag+"="); ok {
+			return v, true
+		}
+		if arg == flag && i+1 < len(args) {
+			return args[i+1], true
+		}
+	}
+	return "", false
+}
+
+func (s *AppService) Environment() string {
+	return s.environment
+}
+
+func (s *AppService) IsDevelopment() bool {
+	return s.environment == contract.EnvDevelopment
+}
+
+func (s *AppService) IsTesting() bool {
+	return s.environment == contract.EnvTesting
+}
+
+func (s *AppService) IsProduction() bool {
+	return s.environment == contract.EnvProduction
+}
+
+func (s *AppService) BaseFolder() string {
+	return s.baseFolder
+}
+
+func (s *AppService) ConfigFolder() string {
+	return filepath.Join(s.baseFolder, "config")
+}
+
+func (s *AppService) EnvConfigFolder() string {
+	return filepath.Join(s.ConfigFolder(), s.environment)
+}
+
+func (s *AppService) StorageFolder() string {
+	return filepath.Join(s.baseFolder, "storage")
+}
+
+func (s *AppService) LogFolder() string {
+	return filepath.Join(s.StorageFolder(), "log")
+}
+
+func (s *AppService) RuntimeFolder() string {
+	return filepath.Join(s.StorageFolder(), "runtime")
+}
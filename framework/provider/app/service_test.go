@@ -0,0 +1,73 @@
+package app
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/RZXBxie/web_server/framework/gin"
+	"github.com/RZXBxie/web_server/framework/provider/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newApp(t *testing.T, sp *AppProvider) (framework.Container, contract.App) {
+	mode := gin.Mode()
+	t.Cleanup(func() { gin.SetMode(mode) })
+	c := framework.NewContainer()
+	require.NoError(t, c.Bind(sp))
+	return c, framework.MustMakeAs[contract.App](c, contract.AppKey)
+}
+
+func TestAppEnvironment(t *testing.T) {
+	t.Setenv("WEBTEST_ENV", "prod")
+	_, app := newApp(t, &AppProvider{EnvName: "WEBTEST_ENV"})
+	assert.Equal(t, contract.EnvProduction, app.Environment())
+	assert.True(t, app.IsProduction())
+	assert.Equal(t, gin.ReleaseMode, gin.Mode())
+
+	// 命令行参数优先于环境变量
+	_, app = newApp(t, &AppProvider{EnvName: "WEBTEST_ENV", Args: []string{"--env", "testing"}})
+	assert.True(t, app.IsTesting())
+	assert.Equal(t, gin.TestMode, gin.Mode())
+
+	_, app = newApp(t, &AppProvider{Args: []string{"--env=dev"}})
+	assert.True(t, app.IsDevelopment())
+	assert.Equal(t, gin.DebugMode, gin.Mode())
+
+	_, app = newApp(t, &AppProvider{})
+	assert.Equal(t, contract.EnvDevelopment, app.Environment())
+
+	c := framework.NewContainer()
+	assert.EqualError(t, c.Bind(&AppProvider{Args: []string{"--env=staging"}}), `app: unknown environment "staging"`)
+}
+
+func TestAppFolders(t *testing.T) {
+	base := t.TempDir()
+	_, app := newApp(t, &AppProvider{BaseFolder: base, Args: []string{"--env=production"}})
+	assert.Equal(t, base, app.BaseFolder())
+	assert.Equal(t, filepath.Join(base, "config"), app.ConfigFolder())
+	assert.Equal(t, filepath.Join(base, "config", "production"), app.EnvConfigFolder())
+	assert.Equal(t, filepath.Join(base, "storage"), app.StorageFolder())
+	assert.Equal(t, filepath.Join(base, "storage", "log"), app.LogFolder())
+	assert.Equal(t, filepath.Join(base, "storage", "runtime"), app.RuntimeFolder())
+
+	_, app = newApp(t, &AppProvider{BaseFolder: base, Args: []string{"--base-folder", "testdata"}})
+	abs, err := filepath.Abs("testdata")
+	require.NoError(t, err)
+	assert.Equal(t, abs, app.BaseFolder())
+}
+
+func TestAppSelectsConfigFolders(t *testing.T) {
+	c, _ := newApp(t, &AppProvider{BaseFolder: "testdata", Args: []string{"--env=production"}})
+	require.NoError(t, c.Bind(&config.ConfigProvider{}))
+	cfg := framework.MustMakeAs[contract.Config](c, contract.ConfigKey)
+	assert.Equal(t, ":8080", cfg.GetString("app.address"))
+	assert.Equal(t, "error", cfg.GetString("app.log_level"))
+
+	c, _ = newApp(t, &AppProvider{BaseFolder: "testdata", Args: []string{"--env=development"}})
+	require.NoError(t, c.Bind(&config.ConfigProvider{}))
+	cfg = framework.MustMakeAs[contract.Config](c, contract.ConfigKey)
+	assert.Equal(t, "debug", cfg.GetString("app.log_level"))
+}
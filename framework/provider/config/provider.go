@@ -0,0 +1,51 @@
+package config
+
+import (
+	"reflect"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+)
+
+// ConfigProvider 提供配置服务，配置的来源见 Source
+type ConfigProvider struct {
+	Source
+}
+
+// Name 将服务对应的字符串凭证返回
+func (sp *ConfigProvider) Name() string {
+	return contract.ConfigKey
+}
+
+// Contract 声明服务实例需要实现contract.Config接口
+func (sp *ConfigProvider) Contract() reflect.Type {
+	return framework.ContractOf[contract.Config]()
+}
+
+// Register 注册配置服务的实例化方法
+func (sp *ConfigProvider) Register(c framework.Container) framework.NewInstance {
+	return NewConfigService
+}
+
+// Boot 配置服务不需要准备工作
+func (sp *ConfigProvider) Boot(c framework.Container) error {
+	return nil
+}
+
+// Params 返回服务容器和配置的来源
+// 没有指定配置目录时，如果已经绑定了应用环境服务，使用它提供的公共配置目录和当前环境的配置目录
+// 所以应用环境服务需要在配置服务之前绑定
+func (sp *ConfigProvider) Params(c framework.Container) []interface{} {
+	source := sp.Source
+	if len(source.Dirs) == 0 && c.IsBind(contract.AppKey) {
+		if app, err := framework.MakeAs[contract.App](c, contract.AppKey); err == nil {
+			source.Dirs = []string{app.ConfigFolder(), app.EnvConfigFolder()}
+		}
+	}
+	return []interface{}{c, source}
+}
+
+// IsDefer 其他服务一般都依赖配置，所以在绑定的时候就实例化，配置文件有问题时可以尽早发现
+func (sp *ConfigProvider) IsDefer() bool {
+	return false
+}
@@ -0,0 +1,349 @@
+package config
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/goccy/go-yaml"
+	"github.com/pelletier/go-toml/v2"
+	"github.com/spf13/cast"
+)
+
+// ConfigService 是配置服务的实现，配置按照下面的顺序合并，后面的来源覆盖前面的来源：
+// 配置目录中的文件、额外指定的配置文件、环境变量、命令行参数
+type ConfigService struct {
+	contract.Config
+
+	// c 服务容器
+	c framework.Container
+
+	// source 配置的来源
+	source Source
+
+	// data 合并之后的配置，重新加载时整体替换，替换之后不再修改
+	data map[string]interface{}
+
+	// files 配置文件的状态，用于判断配置文件是否发生了变化
+	files map[string]fileState
+
+	// watchers 配置项变化的订阅
+	watchers map[int]watcher
+	nextID   int
+
+	// stop 停止监听配置文件的变化
+	stop context.CancelFunc
+
+	// reloadLock 串行化重新加载，保证配置按照加载的顺序替换，订阅者收到的变化前后衔接
+	reloadLock sync.Mutex
+
+	lock sync.RWMutex
+}
+
+// Source 描述了配置的来源
+type Source struct {
+	// Dirs 配置目录，目录中的每个配置文件以文件名作为配置项的第一级，例如 app.yaml 中的 address 对应 app.address
+	// 后面的目录覆盖前面的目录，为空并且绑定了应用环境服务时，使用公共配置目录和当前环境的配置目录
+	Dirs []string
+	// Files 额外的配置文件，内容直接合并到配置的根，后面的文件覆盖前面的文件
+	Files []string
+	// EnvPrefix 环境变量前缀，为空时不读取环境变量
+	// 例如前缀为 WEB_ 时，环境变量 WEB_APP_ADDRESS 对应配置项 app.address
+	// 配置项的名字中有下划线时按照已经存在的配置项匹配，例如 WEB_APP_SHUTDOWN_DELAY 对应 app.shutdown_delay
+	EnvPrefix string
+	// Args 命令行参数，其中 --set key=value 或者 --set=key=value 形式的参数会覆盖配置项
+	Args []string
+	// ReloadInterval 检查配置文件是否变化的间隔，配置文件变化之后自动重新加载，为0时不检查
+	ReloadInterval time.Duration
+}
+
+// NewConfigService 初始化配置服务，参数为服务容器和配置的来源
+func NewConfigService(params ...interface{}) (interface{}, error) {
+	c := params[0].(framework.Container)
+	source := params[1].(Source)
+	files := source.stat()
+	data, err := source.load()
+	if err != nil {
+		return nil, err
+	}
+	return &ConfigService{c: c, source: source, data: data, files: files, watchers: make(map[int]watcher)}, nil
+}
+
+// load 按照优先级从低到高读取并合并所有的配置来源
+func (s Source) load() (map[string]interface{}, error) {
+	data := make(map[string]interface{})
+	for _, dir := range s.Dirs {
+		entries, err := os.ReadDir(dir)
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, entry := range entries {
+			file := filepath.Join(dir, entry.Name())
+			if entry.IsDir() || !isConfigFile(file) {
+				continue
+			}
+			content, err := loadFile(file)
+			if err != nil {
+				return nil, err
+			}
+			name := strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name()))
+			data[name] = merge(data[name], content)
+		}
+	}
+	for _, file := range s.Files {
+		content, err := loadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		data = merge(data, content).(map[string]interface{})
+	}
+	if s.EnvPrefix != "" {
+		for _, env := range os.Environ() {
+			name, value, _ := strings.Cut(env, "=")
+			if !strings.HasPrefix(name, s.EnvPrefix) || name == s.EnvPrefix {
+				continue
+			}
+			set(data, envKey(data, strings.TrimPrefix(name, s.EnvPrefix)), parseValue(value))
+		}
+	}
+	for i := 0; i < len(s.Args); i++ {
+		arg := s.Args[i]
+		var kv string
+		switch {
+		case strings.HasPrefix(arg, "--set="):
+			kv = strings.TrimPrefix(arg, "--set=")
+		case arg == "--set" && i+1 < len(s.Args):
+			i++
+			kv = s.Args[i]
+		default:
+			continue
+		}
+		key, value, ok := strings.Cut(kv, "=")
+		if !ok || key == "" {
+			return nil, fmt.Errorf("config: invalid argument %q, expect --set key=value", kv)
+		}
+		set(data, key, parseValue(value))
+	}
+	return data, nil
+}
+
+// configFiles 返回所有的配置文件，包括配置目录中的配置文件和额外指定的配置文件
+func (s Source) configFiles() []string {
+	var files []string
+	for _, dir := range s.Dirs {
+		entries, _ := os.ReadDir(dir)
+		for _, entry := range entries {
+			file := filepath.Join(dir, entry.Name())
+			if !entry.IsDir() && isConfigFile(file) {
+				files = append(files, file)
+			}
+		}
+	}
+	return append(files, s.Files...)
+}
+
+// isConfigFile 是否是支持的配置文件格式
+func isConfigFile(file string) bool {
+	switch filepath.Ext(file) {
+	case ".yaml", ".yml", ".toml", ".json":
+		return true
+	}
+	return false
+}
+
+// loadFile 根据扩展名解析yaml、toml、json格式的配置文件
+func loadFile(file string) (map[string]interface{}, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	data := make(map[string]interface{})
+	switch filepath.Ext(file) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(content, &data)
+	case ".toml":
+		err = toml.Unmarshal(content, &data)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(content))
+		decoder.UseNumber()
+		err = decoder.Decode(&data)
+	default:
+		return nil, fmt.Errorf("config: unsupported config file %s", file)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config: parse %s: %w", file, err)
+	}
+	return data, nil
+}
+
+// envKey 把去掉前缀的环境变量名还原为配置项的路径，以_分隔的单词中每一层优先匹配已经存在的最长的配置项
+// 例如存在 app.shutdown_delay 时 APP_SHUTDOWN_DELAY 对应它，配置文件中不存在的配置项每个单词作为一层
+func envKey(data map[string]interface{}, name string) string {
+	words := strings.Split(strings.ToLower(name), "_")
+	parts := make([]string, 0, len(words))
+	current := data
+	for i := 0; i < len(words); {
+		part := words[i]
+		for j := len(words); j > i+1; j-- {
+			if _, ok := current[strings.Join(words[i:j], "_")]; ok {
+				part = strings.Join(words[i:j], "_")
+				break
+			}
+		}
+		parts = append(parts, part)
+		current, _ = current[part].(map[string]interface{})
+		i += strings.Count(part, "_") + 1
+	}
+	return strings.Join(parts, ".")
+}
+
+// parseValue 把环境变量和命令行参数中的字符串按照yaml解析，这样 8080、true、[a, b] 都能得到对应的类型
+func parseValue(value string) interface{} {
+	var v interface{}
+	if err := yaml.Unmarshal([]byte(value), &v); err != nil || v == nil {
+		return value
+	}
+	if _, ok := v.(map[string]interface{}); ok {
+		// 形如 key: value 的字符串不作为map处理
+		return value
+	}
+	return v
+}
+
+// merge 深度合并两个配置，src 中的配置覆盖 dst 中的配置
+func merge(dst, src interface{}) interface{} {
+	dstMap, ok1 := dst.(map[string]interface{})
+	srcMap, ok2 := src.(map[string]interface{})
+	if !ok1 || !ok2 {
+		return src
+	}
+	for key, value := range srcMap {
+		dstMap[key] = merge(dstMap[key], value)
+	}
+	return dstMap
+}
+
+// set 按照点号分隔的路径设置配置项，路径中不存在的层级会被创建
+func set(data map[string]interface{}, key string, value interface{}) {
+	parts := strings.Split(key, ".")
+	for _, part := range parts[:len(parts)-1] {
+		next, ok := data[part].(map[string]interface{})
+		if !ok {
+			next = make(map[string]interface{})
+			data[part] = next
+		}
+		data = next
+	}
+	data[parts[len(parts)-1]] = value
+}
+
+// find 按照点号分隔的路径查找配置项
+func find(data map[string]interface{}, key string) (interface{}, bool) {
+	if key == "" {
+		return data, true
+	}
+	var current interface{} = data
+	for _, part := range strings.Split(key, ".") {
+		m, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if current, ok = m[part]; !ok {
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// clone 深度拷贝配置项中的map和数组，其他类型的值直接返回
+func clone(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			m[key] = clone(item)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, item := range v {
+			s[i] = clone(item)
+		}
+		return s
+	}
+	return value
+}
+
+// lookup 在当前的配置中查找配置项
+func (s *ConfigService) lookup(key string) (interface{}, bool) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	return find(s.data, key)
+}
+
+func (s *ConfigService) IsExist(key string) bool {
+	_, ok := s.lookup(key)
+	return ok
+}
+
+// Get 返回配置项的拷贝，调用方修改返回的map和数组不会影响当前的配置
+func (s *ConfigService) Get(key string) interface{} {
+	value, _ := s.lookup(key)
+	return clone(value)
+}
+
+func (s *ConfigService) GetBool(key string) bool {
+	return cast.ToBool(s.Get(key))
+}
+
+func (s *ConfigService) GetInt(key string) int {
+	return cast.ToInt(s.Get(key))
+}
+
+func (s *ConfigService) GetInt64(key string) int64 {
+	return cast.ToInt64(s.Get(key))
+}
+
+func (s *ConfigService) GetFloat64(key string) float64 {
+	return cast.ToFloat64(s.Get(key))
+}
+
+func (s *ConfigService) GetString(key string) string {
+	return cast.ToString(s.Get(key))
+}
+
+func (s *ConfigService) GetDuration(key string) time.Duration {
+	return cast.ToDuration(s.Get(key))
+}
+
+func (s *ConfigService) GetStringSlice(key string) []string {
+	return cast.ToStringSlice(s.Get(key))
+}
+
+func (s *ConfigService) GetStringMap(key string) map[string]interface{} {
+	return cast.ToStringMap(s.Get(key))
+}
+
+func (s *ConfigService) GetStringMapString(key string) map[string]string {
+	return cast.ToStringMapString(s.Get(key))
+}
+
+func (s *ConfigService) Load(key string, val interface{}) error {
+	value, ok := s.lookup(key)
+	if !ok {
+		return fmt.Errorf("config: key %s not found", key)
+	}
+	content, err := yaml.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return yaml.Unmarshal(content, val)
+}
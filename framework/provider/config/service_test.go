@@ -0,0 +1,116 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newConfig(t *testing.T, source Source) contract.Config {
+	c := framework.NewContainer()
+	require.NoError(t, c.Bind(&ConfigProvider{Source: source}))
+	return framework.MustMakeAs[contract.Config](c, contract.ConfigKey)
+}
+
+func TestConfigFiles(t *testing.T) {
+	cfg := newConfig(t, Source{Dirs: []string{"testdata/conf"}, Files: []string{"testdata/override.yaml"}})
+
+	assert.Equal(t, ":8080", cfg.GetString("app.address"))
+	assert.Equal(t, 5*time.Second, cfg.GetDuration("app.timeout"))
+	assert.True(t, cfg.GetBool("app.debug"))
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, cfg.GetStringSlice("app.hosts"))
+
+	assert.Equal(t, "mysql", cfg.GetString("database.driver"))
+	assert.Equal(t, 3306, cfg.GetInt("database.port"))
+	assert.Equal(t, int64(10), cfg.GetInt64("database.pool.max_open"))
+	assert.Equal(t, 2, cfg.GetInt("database.pool.max_idle"))
+
+	assert.Equal(t, time.Minute, cfg.GetDuration("cache.ttl"))
+	assert.Equal(t, 128, cfg.GetInt("cache.size"))
+	assert.InDelta(t, 0.5, cfg.GetFloat64("cache.ratio"), 0.0001)
+	assert.Equal(t, map[string]string{"env": "test"}, cfg.GetStringMapString("cache.labels"))
+	assert.Equal(t, map[string]interface{}{"env": "test"}, cfg.GetStringMap("cache.labels"))
+
+	assert.True(t, cfg.IsExist("database.pool"))
+	assert.False(t, cfg.IsExist("database.pool.missing"))
+	assert.False(t, cfg.IsExist("app.address.missing"))
+	assert.Nil(t, cfg.Get("missing"))
+	assert.Equal(t, 0, cfg.GetInt("missing"))
+
+	// 修改返回的map和数组不影响配置
+	cfg.GetStringMap("cache.labels")["env"] = "prod"
+	cfg.Get("app").(map[string]interface{})["address"] = ":9090"
+	cfg.Get("app.hosts").([]interface{})[0] = "c.example.com"
+	assert.Equal(t, "test", cfg.GetString("cache.labels.env"))
+	assert.Equal(t, ":8080", cfg.GetString("app.address"))
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, cfg.GetStringSlice("app.hosts"))
+}
+
+func TestConfigEnvAndArgs(t *testing.T) {
+	t.Setenv("WEBTEST_APP_ADDRESS", ":9090")
+	t.Setenv("WEBTEST_DATABASE_PORT", "3307")
+	t.Setenv("WEBTEST_APP_HOSTS", "[c.example.com]")
+	t.Setenv("WEBTEST_APP_SHUTDOWN_DELAY", "9s")
+	t.Setenv("WEBTEST_DATABASE_POOL_MAX_OPEN", "20")
+	t.Setenv("WEBTEST_APP_NEW_KEY", "x")
+	cfg := newConfig(t, Source{
+		Dirs:      []string{"testdata/conf"},
+		EnvPrefix: "WEBTEST_",
+		Args:      []string{"serve", "--set", "database.port=3308", "--set=app.timeout=10s", "--verbose"},
+	})
+
+	assert.Equal(t, ":9090", cfg.GetString("app.address"))
+	assert.Equal(t, 3308, cfg.GetInt("database.port"))
+	assert.Equal(t, 10*time.Second, cfg.GetDuration("app.timeout"))
+	assert.Equal(t, []string{"c.example.com"}, cfg.GetStringSlice("app.hosts"))
+	// 名字中有下划线的配置项按照已经存在的配置项匹配
+	assert.Equal(t, 9*time.Second, cfg.GetDuration("app.shutdown_delay"))
+	assert.False(t, cfg.IsExist("app.shutdown.delay"))
+	assert.Equal(t, 20, cfg.GetInt("database.pool.max_open"))
+	// 不存在的配置项每个单词作为一层
+	assert.Equal(t, "x", cfg.GetString("app.new.key"))
+}
+
+func TestConfigLoad(t *testing.T) {
+	t.Setenv("WEBTEST_DATABASE_PORT", "3307")
+	cfg := newConfig(t, Source{Dirs: []string{"testdata/conf"}, EnvPrefix: "WEBTEST_"})
+
+	var db struct {
+		Driver string `yaml:"driver"`
+		Port   int    `yaml:"port"`
+		Pool   struct {
+			MaxOpen int `yaml:"max_open"`
+		} `yaml:"pool"`
+	}
+	require.NoError(t, cfg.Load("database", &db))
+	assert.Equal(t, "mysql", db.Driver)
+	assert.Equal(t, 3307, db.Port)
+	assert.Equal(t, 10, db.Pool.MaxOpen)
+
+	var cache struct {
+		TTL  time.Duration `yaml:"ttl"`
+		Size int           `yaml:"size"`
+	}
+	require.NoError(t, cfg.Load("cache", &cache))
+	assert.Equal(t, time.Minute, cache.TTL)
+	assert.Equal(t, 128, cache.Size)
+
+	assert.EqualError(t, cfg.Load("missing", &cache), "config: key missing not found")
+}
+
+func TestConfigErrors(t *testing.T) {
+	c := framework.NewContainer()
+	err := c.Bind(&ConfigProvider{Source: Source{Files: []string{"testdata/missing.yaml"}}})
+	assert.Error(t, err)
+
+	err = c.Bind(&ConfigProvider{Source: Source{Args: []string{"--set", "novalue"}}})
+	assert.EqualError(t, err, `config: invalid argument "novalue", expect --set key=value`)
+
+	// 配置目录不存在时不报错，方便只使用环境变量和命令行参数
+	cfg := newConfig(t, Source{Dirs: []string{"testdata/missing"}})
+	assert.False(t, cfg.IsExist("app"))
+}
@@ -0,0 +1,184 @@
+package config
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework/contract"
+)
+
+// fileState 记录配置文件的修改时间和大小，任意一个变化都认为文件发生了变化
+type fileState struct {
+	modTime time.Time
+	size    int64
+}
+
+// watcher 是一个配置项变化的订阅
+type watcher struct {
+	prefix   string
+	callback func(changes []contract.ConfigChange)
+}
+
+// stat 获取所有配置文件的状态，不存在的文件不记录
+func (s Source) stat() map[string]fileState {
+	files := make(map[string]fileState)
+	for _, file := range s.configFiles() {
+		if info, err := os.Stat(file); err == nil {
+			files[file] = fileState{modTime: info.ModTime(), size: info.Size()}
+		}
+	}
+	return files
+}
+
+// Start 设置了ReloadInterval时，启动一个goroutine定期检查配置文件，发生变化时重新加载
+// 服务容器关闭时ctx被取消，goroutine随之退出
+func (s *ConfigService) Start(ctx context.Context) error {
+	if s.source.ReloadInterval <= 0 {
+		return nil
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	s.lock.Lock()
+	s.stop = cancel
+	s.lock.Unlock()
+
+	go func() {
+		ticker := time.NewTicker(s.source.ReloadInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.reloadIfChanged()
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop 停止检查配置文件
+func (s *ConfigService) Stop(ctx context.Context) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.stop != nil {
+		s.stop()
+	}
+	return nil
+}
+
+// reloadIfChanged 配置文件发生变化时重新加载，加载失败时保留当前的配置，下一次检查时重试
+func (s *ConfigService) reloadIfChanged() {
+	files := s.source.stat()
+	s.lock.RLock()
+	changed := !reflect.DeepEqual(files, s.files)
+	s.lock.RUnlock()
+	if changed {
+		_ = s.Reload()
+	}
+}
+
+// Reload 从读取配置到通知订阅者的整个过程持有reloadLock，定期检查和手动调用同时发生时依次执行
+// 所以订阅者的回调中不能调用Reload
+func (s *ConfigService) Reload() error {
+	s.reloadLock.Lock()
+	defer s.reloadLock.Unlock()
+
+	files := s.source.stat()
+	data, err := s.source.load()
+	if err != nil {
+		return err
+	}
+
+	s.lock.Lock()
+	old := s.data
+	s.data = data
+	s.files = files
+	watchers := make([]watcher, 0, len(s.watchers))
+	ids := make([]int, 0, len(s.watchers))
+	for id := range s.watchers {
+		ids = append(ids, id)
+	}
+	// 按照订阅的顺序通知
+	sort.Ints(ids)
+	for _, id := range ids {
+		watchers = append(watchers, s.watchers[id])
+	}
+	s.lock.Unlock()
+
+	changes := diff(old, data)
+	if len(changes) == 0 {
+		return nil
+	}
+	for _, w := range watchers {
+		var matched []contract.ConfigChange
+		for _, change := range changes {
+			if hasPrefix(change.Key, w.prefix) {
+				matched = append(matched, change)
+			}
+		}
+		if len(matched) > 0 {
+			w.callback(matched)
+		}
+	}
+	return nil
+}
+
+func (s *ConfigService) Watch(prefix string, callback func(changes []contract.ConfigChange)) func() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	id := s.nextID
+	s.nextID++
+	s.watchers[id] = watcher{prefix: prefix, callback: callback}
+	return func() {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		delete(s.watchers, id)
+	}
+}
+
+// hasPrefix 配置项key是否是prefix本身或者在prefix之下
+func hasPrefix(key, prefix string) bool {
+	return prefix == "" || key == prefix || strings.HasPrefix(key, prefix+".")
+}
+
+// diff 比较两份配置，返回所有发生变化的叶子配置项，按照配置项排序
+func diff(old, new map[string]interface{}) []contract.ConfigChange {
+	oldLeaves, newLeaves := make(map[string]interface{}), make(map[string]interface{})
+	flatten("", old, oldLeaves)
+	flatten("", new, newLeaves)
+
+	var changes []contract.ConfigChange
+	for key, oldValue := range oldLeaves {
+		newValue, ok := newLeaves[key]
+		if !ok || !reflect.DeepEqual(oldValue, newValue) {
+			changes = append(changes, contract.ConfigChange{Key: key, Old: oldValue, New: newValue})
+		}
+	}
+	for key, newValue := range newLeaves {
+		if _, ok := oldLeaves[key]; !ok {
+			changes = append(changes, contract.ConfigChange{Key: key, New: newValue})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Key < changes[j].Key
+	})
+	return changes
+}
+
+// flatten 把嵌套的配置展开为点号分隔的叶子配置项
+func flatten(prefix string, data map[string]interface{}, leaves map[string]interface{}) {
+	for key, value := range data {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if m, ok := value.(map[string]interface{}); ok && len(m) > 0 {
+			flatten(key, m, leaves)
+			continue
+		}
+		leaves[key] = clone(value)
+	}
+}
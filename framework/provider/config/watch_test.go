@@ -0,0 +1,122 @@
+package config
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFile(t *testing.T, file, content string) {
+	require.NoError(t, os.WriteFile(file, []byte(content), 0o644))
+	// 保证修改时间发生变化，有些文件系统的时间精度比较低
+	next := time.Now().Add(time.Duration(len(content)) * time.Second)
+	require.NoError(t, os.Chtimes(file, next, next))
+}
+
+func TestConfigReloadNotifiesWatchers(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yaml")
+	writeFile(t, file, "timeout: 5s\nname: web\ndb:\n  port: 3306\n")
+	cfg := newConfig(t, Source{Dirs: []string{dir}})
+
+	var appChanges, timeoutChanges []contract.ConfigChange
+	cfg.Watch("app", func(changes []contract.ConfigChange) { appChanges = append(appChanges, changes...) })
+	cancel := cfg.Watch("app.timeout", func(changes []contract.ConfigChange) {
+		timeoutChanges = append(timeoutChanges, changes...)
+	})
+	cfg.Watch("app.name", func(changes []contract.ConfigChange) { t.Fatal("app.name not changed") })
+
+	writeFile(t, file, "timeout: 10s\nname: web\ndb:\n  host: localhost\n")
+	require.NoError(t, cfg.Reload())
+	assert.Equal(t, 10*time.Second, cfg.GetDuration("app.timeout"))
+	assert.Equal(t, []contract.ConfigChange{
+		{Key: "app.db.host", New: "localhost"},
+		{Key: "app.db.port", Old: uint64(3306)},
+		{Key: "app.timeout", Old: "5s", New: "10s"},
+	}, appChanges)
+	assert.Equal(t, []contract.ConfigChange{{Key: "app.timeout", Old: "5s", New: "10s"}}, timeoutChanges)
+
+	cancel()
+	writeFile(t, file, "timeout: 20s\nname: web\n")
+	require.NoError(t, cfg.Reload())
+	assert.Len(t, timeoutChanges, 1)
+}
+
+func TestConfigConcurrentReload(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yaml")
+	writeFile(t, file, "version: 0\n")
+	cfg := newConfig(t, Source{Dirs: []string{dir}})
+
+	var changes []contract.ConfigChange
+	cfg.Watch("app.version", func(c []contract.ConfigChange) { changes = append(changes, c...) })
+
+	var wg sync.WaitGroup
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			// 先写入临时文件再重命名，重新加载时不会读到写了一半的文件
+			tmp := filepath.Join(dir, strconv.Itoa(i)+".tmp")
+			assert.NoError(t, os.WriteFile(tmp, []byte("version: "+strconv.Itoa(i)+"\n"), 0o644))
+			assert.NoError(t, os.Rename(tmp, file))
+			assert.NoError(t, cfg.Reload())
+		}(i)
+	}
+	wg.Wait()
+
+	// 重新加载依次执行，每次变化的旧值都是上一次变化的新值
+	require.NotEmpty(t, changes)
+	assert.Equal(t, uint64(0), changes[0].Old)
+	for i := 1; i < len(changes); i++ {
+		assert.Equal(t, changes[i-1].New, changes[i].Old)
+	}
+	assert.Equal(t, changes[len(changes)-1].New, cfg.Get("app.version"))
+}
+
+func TestConfigReloadKeepsConfigOnError(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yaml")
+	writeFile(t, file, "timeout: 5s\n")
+	cfg := newConfig(t, Source{Dirs: []string{dir}})
+
+	writeFile(t, file, "timeout: [5s\n")
+	assert.Error(t, cfg.Reload())
+	assert.Equal(t, 5*time.Second, cfg.GetDuration("app.timeout"))
+}
+
+func TestConfigWatchFiles(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.yaml")
+	writeFile(t, file, "timeout: 5s\n")
+
+	c := framework.NewContainer()
+	require.NoError(t, c.Bind(&ConfigProvider{Source: Source{Dirs: []string{dir}, ReloadInterval: 10 * time.Millisecond}}))
+	cfg := framework.MustMakeAs[contract.Config](c, contract.ConfigKey)
+
+	changed := make(chan struct{}, 1)
+	cfg.Watch("app.timeout", func(changes []contract.ConfigChange) {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	writeFile(t, file, "timeout: 10s\n")
+
+	select {
+	case <-changed:
+	case <-time.After(5 * time.Second):
+		t.Fatal("config file change not detected")
+	}
+	assert.Equal(t, 10*time.Second, cfg.GetDuration("app.timeout"))
+	require.NoError(t, c.Shutdown(context.Background()))
+}
@@ -0,0 +1,39 @@
+package health
+
+import (
+	"net/http"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/RZXBxie/web_server/framework/gin"
+)
+
+// LivenessHandler 以json格式输出存活检查的结果，需要绑定 HealthProvider
+func LivenessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		health := framework.MustMakeAs[contract.Health](c, contract.HealthKey)
+		writeReport(c, health.Live(c.Request.Context()))
+	}
+}
+
+// ReadinessHandler 以json格式输出就绪检查的结果，没有就绪时状态码为503，需要绑定 HealthProvider
+func ReadinessHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		health := framework.MustMakeAs[contract.Health](c, contract.HealthKey)
+		writeReport(c, health.Ready(c.Request.Context()))
+	}
+}
+
+// RegisterRoutes 把存活检查挂载到/healthz，把就绪检查挂载到/readyz
+func RegisterRoutes(routes gin.IRoutes) {
+	routes.GET("/healthz", LivenessHandler())
+	routes.GET("/readyz", ReadinessHandler())
+}
+
+func writeReport(c *gin.Context, report contract.HealthReport) {
+	status := http.StatusOK
+	if report.Status == contract.HealthDown {
+		status = http.StatusServiceUnavailable
+	}
+	c.ISetStatus(status).IJson(report)
+}
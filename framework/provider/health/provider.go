@@ -0,0 +1,54 @@
+package health
+
+import (
+	"reflect"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+)
+
+// HealthProvider 提供健康检查服务
+type HealthProvider struct {
+	// Timeout 每一项检查的超时时间，为0时使用2秒
+	Timeout time.Duration
+	// CacheTTL 检查结果的缓存时间，避免频繁的探测压垮依赖，为0时使用1秒，小于0时不缓存
+	CacheTTL time.Duration
+}
+
+// Name 将服务对应的字符串凭证返回
+func (sp *HealthProvider) Name() string {
+	return contract.HealthKey
+}
+
+// Contract 声明服务实例需要实现contract.Health接口
+func (sp *HealthProvider) Contract() reflect.Type {
+	return framework.ContractOf[contract.Health]()
+}
+
+// Register 注册健康检查服务的实例化方法
+func (sp *HealthProvider) Register(c framework.Container) framework.NewInstance {
+	return NewHealthService
+}
+
+// Boot 健康检查服务不需要准备工作
+func (sp *HealthProvider) Boot(c framework.Container) error {
+	return nil
+}
+
+// Params 返回服务容器、检查的超时时间和缓存时间
+func (sp *HealthProvider) Params(c framework.Container) []interface{} {
+	timeout, ttl := sp.Timeout, sp.CacheTTL
+	if timeout == 0 {
+		timeout = 2 * time.Second
+	}
+	if ttl == 0 {
+		ttl = time.Second
+	}
+	return []interface{}{c, timeout, ttl}
+}
+
+// IsDefer 在第一次探测或者关闭的时候实例化
+func (sp *HealthProvider) IsDefer() bool {
+	return true
+}
@@ -0,0 +1,210 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+)
+
+// HealthService 是健康检查服务的实现
+// 就绪检查包括通过AddCheck注册的检查，以及容器中已经实例化的实现了 contract.HealthChecker 的单例
+// 还没有实例化的服务不会因为健康检查而被实例化
+type HealthService struct {
+	contract.Health
+
+	// c 服务容器，从中查找实现了 contract.HealthChecker 的服务
+	c framework.Container
+
+	// timeout 每一项检查的超时时间
+	timeout time.Duration
+
+	// ttl 检查结果的缓存时间
+	ttl time.Duration
+
+	// checks 通过AddCheck注册的检查
+	checks map[string]check
+
+	// cache 缓存的检查结果，key为检查的名字
+	cache map[string]contract.HealthCheck
+
+	// shuttingDown 服务是否正在关闭
+	shuttingDown atomic.Bool
+
+	lock sync.Mutex
+}
+
+// check 是一项注册的检查
+type check struct {
+	critical bool
+	fn       func(ctx context.Context) error
+}
+
+// NewHealthService 初始化健康检查服务，参数为服务容器、检查的超时时间和缓存时间
+func NewHealthService(params ...interface{}) (interface{}, error) {
+	c := params[0].(framework.Container)
+	timeout := params[1].(time.Duration)
+	ttl := params[2].(time.Duration)
+	return &HealthService{
+		c:       c,
+		timeout: timeout,
+		ttl:     ttl,
+		checks:  make(map[string]check),
+		cache:   make(map[string]contract.HealthCheck),
+	}, nil
+}
+
+func (s *HealthService) AddCheck(name string, critical bool, fn func(ctx context.Context) error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.checks[name] = check{critical: critical, fn: fn}
+	delete(s.cache, name)
+}
+
+func (s *HealthService) Live(ctx context.Context) contract.HealthReport {
+	return contract.HealthReport{Status: contract.HealthUp}
+}
+
+// Ready 所有的检查并发执行，单项检查超时按照失败处理
+func (s *HealthService) Ready(ctx context.Context) contract.HealthReport {
+	if s.IsShuttingDown() {
+		return contract.HealthReport{Status: contract.HealthDown, Checks: []contract.HealthCheck{{
+			Name:      "shutdown",
+			Status:    contract.HealthDown,
+			Critical:  true,
+			Error:     "service is shutting down",
+			CheckedAt: time.Now(),
+		}}}
+	}
+
+	checks := s.collect()
+	results := make([]contract.HealthCheck, 0, len(checks))
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	for name, ck := range checks {
+		if cached, ok := s.cached(name); ok {
+			results = append(results, cached)
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := s.run(ctx, name, ck)
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+
+	slices.SortFunc(results, func(a, b contract.HealthCheck) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	report := contract.HealthReport{Status: contract.HealthUp, Checks: results}
+	for _, result := range results {
+		if result.Status == contract.HealthUp {
+			continue
+		}
+		if result.Critical {
+			report.Status = contract.HealthDown
+			break
+		}
+		report.Status = contract.HealthDegraded
+	}
+	return report
+}
+
+// collect 收集所有需要执行的检查，注册的检查和容器中的服务同名时使用注册的检查
+func (s *HealthService) collect() map[string]check {
+	checks := make(map[string]check)
+	for _, info := range s.c.Bindings() {
+		if info.Key == contract.HealthKey || info.Lifetime != framework.Singleton.String() || !info.Instantiated {
+			continue
+		}
+		instance, err := s.c.Make(info.Key)
+		if err != nil {
+			continue
+		}
+		checker, ok := instance.(contract.HealthChecker)
+		if !ok {
+			continue
+		}
+		critical := true
+		if c, ok := instance.(contract.HealthCritical); ok {
+			critical = c.HealthCritical()
+		}
+		checks[info.Key] = check{critical: critical, fn: checker.HealthCheck}
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for name, ck := range s.checks {
+		checks[name] = ck
+	}
+	return checks
+}
+
+// cached 获取还没有过期的检查结果
+func (s *HealthService) cached(name string) (contract.HealthCheck, bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	result, ok := s.cache[name]
+	if !ok || time.Since(result.CheckedAt) >= s.ttl {
+		return contract.HealthCheck{}, false
+	}
+	return result, true
+}
+
+// run 执行一项检查并缓存结果，检查超时或者panic都按照失败处理
+func (s *HealthService) run(ctx context.Context, name string, ck check) contract.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	result := contract.HealthCheck{Name: name, Critical: ck.critical, CheckedAt: time.Now()}
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("panic: %v", p)
+			}
+		}()
+		done <- ck.fn(ctx)
+	}()
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = fmt.Errorf("health check timed out after %v", s.timeout)
+	}
+	result.Duration = time.Since(result.CheckedAt)
+	result.Status = contract.HealthUp
+	if err != nil {
+		result.Status, result.Error = contract.HealthDown, err.Error()
+	}
+
+	if s.ttl > 0 {
+		s.lock.Lock()
+		s.cache[name] = result
+		s.lock.Unlock()
+	}
+	return result
+}
+
+func (s *HealthService) SetShuttingDown() {
+	s.shuttingDown.Store(true)
+}
+
+func (s *HealthService) IsShuttingDown() bool {
+	return s.shuttingDown.Load()
+}
+
+// Stop 容器关闭时也标记服务正在关闭，正常情况下应该在http.Server.Shutdown之前调用SetShuttingDown
+func (s *HealthService) Stop(ctx context.Context) error {
+	s.SetShuttingDown()
+	return nil
+}
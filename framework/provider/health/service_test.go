@@ -0,0 +1,149 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/RZXBxie/web_server/framework/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// checkedService 是实现了健康检查的服务，记录检查的次数
+type checkedService struct {
+	err      error
+	delay    time.Duration
+	critical bool
+	calls    atomic.Int32
+}
+
+func (s *checkedService) HealthCheck(ctx context.Context) error {
+	s.calls.Add(1)
+	select {
+	case <-time.After(s.delay):
+		return s.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s *checkedService) HealthCritical() bool { return s.critical }
+
+// serviceProvider 把一个已经创建好的服务绑定到容器中
+type serviceProvider struct {
+	name     string
+	instance interface{}
+}
+
+func (p *serviceProvider) Name() string                             { return p.name }
+func (p *serviceProvider) IsDefer() bool                            { return true }
+func (p *serviceProvider) Boot(framework.Container) error           { return nil }
+func (p *serviceProvider) Params(framework.Container) []interface{} { return nil }
+func (p *serviceProvider) Register(framework.Container) framework.NewInstance {
+	return func(...interface{}) (interface{}, error) { return p.instance, nil }
+}
+
+func newHealth(t *testing.T, sp *HealthProvider, services map[string]interface{}) (*framework.MyContainer, contract.Health) {
+	c := framework.NewContainer()
+	require.NoError(t, c.Bind(sp))
+	for name, instance := range services {
+		require.NoError(t, c.Bind(&serviceProvider{name: name, instance: instance}))
+	}
+	return c, framework.MustMakeAs[contract.Health](c, contract.HealthKey)
+}
+
+func TestReadyAggregatesChecks(t *testing.T) {
+	db := &checkedService{critical: true}
+	cache := &checkedService{err: errors.New("cache unavailable")}
+	idle := &checkedService{err: errors.New("not instantiated")}
+	c, health := newHealth(t, &HealthProvider{CacheTTL: -1}, map[string]interface{}{"db": db, "cache": cache, "idle": idle})
+	c.MustMake("db")
+
+	report := health.Ready(context.Background())
+	assert.Equal(t, contract.HealthUp, report.Status)
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, "db", report.Checks[0].Name)
+
+	// 非关键的检查失败时降级
+	c.MustMake("cache")
+	report = health.Ready(context.Background())
+	assert.Equal(t, contract.HealthDegraded, report.Status)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, "cache", report.Checks[0].Name)
+	assert.Equal(t, contract.HealthDown, report.Checks[0].Status)
+	assert.Equal(t, "cache unavailable", report.Checks[0].Error)
+	assert.False(t, report.Checks[0].Critical)
+
+	// 关键的检查失败时不再就绪
+	health.AddCheck("upstream", true, func(ctx context.Context) error { return errors.New("connection refused") })
+	report = health.Ready(context.Background())
+	assert.Equal(t, contract.HealthDown, report.Status)
+	assert.Len(t, report.Checks, 3)
+	assert.Zero(t, idle.calls.Load())
+	assert.Equal(t, contract.HealthUp, health.Live(context.Background()).Status)
+}
+
+func TestReadyTimeoutAndCache(t *testing.T) {
+	slow := &checkedService{delay: time.Second, critical: true}
+	c, health := newHealth(t, &HealthProvider{Timeout: 20 * time.Millisecond, CacheTTL: time.Hour}, map[string]interface{}{"slow": slow})
+	c.MustMake("slow")
+
+	start := time.Now()
+	report := health.Ready(context.Background())
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, contract.HealthDown, report.Status)
+	assert.Equal(t, "health check timed out after 20ms", report.Checks[0].Error)
+
+	// 缓存时间内不会重复执行检查
+	cached := health.Ready(context.Background())
+	assert.Equal(t, report, cached)
+	assert.EqualValues(t, 1, slow.calls.Load())
+}
+
+func TestReadyDuringShutdown(t *testing.T) {
+	c, health := newHealth(t, &HealthProvider{}, nil)
+	assert.Equal(t, contract.HealthUp, health.Ready(context.Background()).Status)
+	assert.False(t, health.IsShuttingDown())
+
+	require.NoError(t, c.Shutdown(context.Background()))
+	assert.True(t, health.IsShuttingDown())
+	report := health.Ready(context.Background())
+	assert.Equal(t, contract.HealthDown, report.Status)
+	assert.Equal(t, "service is shutting down", report.Checks[0].Error)
+	assert.Equal(t, contract.HealthUp, health.Live(context.Background()).Status)
+}
+
+func TestHealthRoutes(t *testing.T) {
+	engine := gin.New()
+	c, health := newHealth(t, &HealthProvider{CacheTTL: -1}, nil)
+	engine.SetContainer(c)
+	RegisterRoutes(engine)
+
+	get := func(path string) (int, contract.HealthReport) {
+		w := httptest.NewRecorder()
+		engine.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		var report contract.HealthReport
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &report))
+		return w.Code, report
+	}
+
+	code, report := get("/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, contract.HealthUp, report.Status)
+
+	health.SetShuttingDown()
+	code, report = get("/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, contract.HealthDown, report.Status)
+	code, report = get("/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, contract.HealthUp, report.Status)
+}
@@ -0,0 +1,108 @@
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework/contract"
+)
+
+// Formatter 把一条日志格式化为一行输出，返回的内容需要以换行结尾
+type Formatter func(entry contract.LogEntry) ([]byte, error)
+
+// timeFormat 日志中时间的格式，精确到毫秒
+const timeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// TextFormatter 把日志格式化为 时间 [级别] 消息 key=value 的形式，字段按照名字排序
+func TextFormatter(entry contract.LogEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(entry.Time.Format(timeFormat))
+	buf.WriteString(" [")
+	buf.WriteString(entry.Level.String())
+	buf.WriteString("] ")
+	buf.WriteString(entry.Msg)
+	for _, key := range sortedKeys(entry.Fields) {
+		buf.WriteByte(' ')
+		buf.WriteString(key)
+		buf.WriteByte('=')
+		buf.WriteString(textValue(entry.Fields[key]))
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// JsonFormatter 把日志格式化为一行json，time、level、msg 之外的字段直接放在json的第一层
+// 字段和这三个字段同名时被这三个字段覆盖
+func JsonFormatter(entry contract.LogEntry) ([]byte, error) {
+	data := make(map[string]interface{}, len(entry.Fields)+3)
+	for key, value := range entry.Fields {
+		data[key] = jsonValue(value)
+	}
+	data["time"] = entry.Time.Format(timeFormat)
+	data["level"] = entry.Level.String()
+	data["msg"] = entry.Msg
+	content, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	return append(content, '\n'), nil
+}
+
+// formatterOf 根据名字获取格式化方法，为空时使用text
+func formatterOf(name string) (Formatter, error) {
+	switch strings.ToLower(name) {
+	case "", "text":
+		return TextFormatter, nil
+	case "json":
+		return JsonFormatter, nil
+	}
+	return nil, fmt.Errorf("log: unknown formatter %q", name)
+}
+
+func sortedKeys(fields map[string]interface{}) []string {
+	keys := make([]string, 0, len(fields))
+	for key := range fields {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// textValue 把字段的值转换为文本，包含空白、引号或者等号的值会加上引号
+func textValue(value interface{}) string {
+	var s string
+	switch v := value.(type) {
+	case string:
+		s = v
+	case error:
+		s = v.Error()
+	case time.Duration:
+		s = v.String()
+	case time.Time:
+		s = v.Format(timeFormat)
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		s = fmt.Sprint(v)
+	}
+	if s == "" || strings.ContainsAny(s, " \t\r\n\"=") {
+		return strconv.Quote(s)
+	}
+	return s
+}
+
+// jsonValue error序列化之后是空对象，这里转换为错误信息
+func jsonValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case time.Duration:
+		return v.String()
+	}
+	return value
+}
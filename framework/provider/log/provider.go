@@ -0,0 +1,48 @@
+package log
+
+import (
+	"io"
+	"reflect"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+)
+
+// LogProvider 提供日志服务
+// 没有指定Config时，如果已经绑定了配置服务，使用配置服务中的log配置项，所以配置服务需要在日志服务之前绑定
+// 相对路径的日志文件放在应用环境服务的日志目录中
+type LogProvider struct {
+	Config
+	// Writers 额外的输出目标，和配置中的输出目标同时使用，一般用于测试
+	Writers []io.Writer
+}
+
+// Name 将服务对应的字符串凭证返回
+func (sp *LogProvider) Name() string {
+	return contract.LogKey
+}
+
+// Contract 声明服务实例需要实现contract.Log接口
+func (sp *LogProvider) Contract() reflect.Type {
+	return framework.ContractOf[contract.Log]()
+}
+
+// Register 注册日志服务的实例化方法
+func (sp *LogProvider) Register(c framework.Container) framework.NewInstance {
+	return NewLogService
+}
+
+// Boot 日志服务不需要准备工作
+func (sp *LogProvider) Boot(c framework.Container) error {
+	return nil
+}
+
+// Params 返回服务容器、日志配置和额外的输出目标
+func (sp *LogProvider) Params(c framework.Container) []interface{} {
+	return []interface{}{c, sp.Config, sp.Writers}
+}
+
+// IsDefer 日志配置有问题时在启动的时候就暴露出来
+func (sp *LogProvider) IsDefer() bool {
+	return false
+}
@@ -0,0 +1,362 @@
+package log
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// RotateInterval 按照时间切割日志文件的周期
+type RotateInterval string
+
+const (
+	// RotateNever 不按照时间切割
+	RotateNever RotateInterval = ""
+	// RotateHourly 每个整点切割
+	RotateHourly RotateInterval = "hourly"
+	// RotateDaily 每天零点切割
+	RotateDaily RotateInterval = "daily"
+)
+
+// backupTimeFormat 旧文件名中切割时间的格式
+const backupTimeFormat = "20060102T150405.000"
+
+// RotateOptions 日志文件切割和保留的规则
+type RotateOptions struct {
+	// MaxSize 单个文件的最大字节数，写入之后会超过时先切割，为0时不按照大小切割
+	MaxSize int64
+	// Interval 按照时间切割的周期
+	Interval RotateInterval
+	// MaxBackups 最多保留的旧文件的数量，为0时不限制
+	MaxBackups int
+	// MaxAge 旧文件保留的时间，按照文件名中的切割时间计算，为0时不限制
+	MaxAge time.Duration
+	// Compress 是否在后台把旧文件压缩为gzip
+	Compress bool
+}
+
+// RotateSink 是按照大小和时间切割的日志文件，实现了io.Writer，可以同时用于日志服务和gin.LoggerConfig的Output
+// 切割时 app.log 重命名为 app-20060102T150405.000.log，压缩之后为 app-20060102T150405.000.log.gz
+// 同一毫秒内切割多次时，后面的旧文件加上序号，例如 app-20060102T150405.000-1.log
+// 切割失败时继续写入原来的文件，错误输出到标准错误，下一次写入时重试
+// 压缩和清理旧文件在后台进行，不阻塞写入；收到SIGHUP时重新打开文件，配合外部的logrotate使用
+type RotateSink struct {
+	file string
+	opts RotateOptions
+
+	// f 当前的日志文件，切割失败并且没能重新打开时为nil，下一次写入时重试
+	f    *os.File
+	size int64
+
+	// next 下一次按照时间切割的时间，不按照时间切割时为零值
+	next time.Time
+
+	// now 获取当前时间
+	now func() time.Time
+
+	// rename 重命名文件，测试中用来模拟切割失败
+	rename func(oldpath, newpath string) error
+
+	// mill 通知后台的goroutine压缩和清理旧文件，millDone 在goroutine退出时关闭
+	mill     chan struct{}
+	millDone chan struct{}
+
+	// signals 接收SIGHUP信号
+	signals chan os.Signal
+
+	closed bool
+	lock   sync.Mutex
+}
+
+// NewRotateSink 打开按照opts切割的日志文件，目录不存在时创建
+func NewRotateSink(file string, opts RotateOptions) (*RotateSink, error) {
+	return newRotateSink(file, opts, time.Now)
+}
+
+// newRotateSink 使用now获取当前时间，测试中用来模拟时间的流逝
+func newRotateSink(file string, opts RotateOptions, now func() time.Time) (*RotateSink, error) {
+	if file == "" {
+		return nil, fmt.Errorf("log: rotate sink requires a file")
+	}
+	switch opts.Interval {
+	case RotateNever, RotateHourly, RotateDaily:
+	default:
+		return nil, fmt.Errorf("log: unknown rotate interval %q", opts.Interval)
+	}
+	s := &RotateSink{
+		file:     file,
+		opts:     opts,
+		now:      now,
+		rename:   os.Rename,
+		mill:     make(chan struct{}, 1),
+		millDone: make(chan struct{}),
+		signals:  make(chan os.Signal, 1),
+	}
+	if err := s.open(); err != nil {
+		return nil, err
+	}
+	go s.millRun()
+	// 启动时处理上次运行留下的旧文件
+	s.mill <- struct{}{}
+
+	signal.Notify(s.signals, syscall.SIGHUP)
+	go func() {
+		for range s.signals {
+			if err := s.Reopen(); err != nil {
+				fmt.Fprintf(os.Stderr, "log: reopen %s: %v\n", s.file, err)
+			}
+		}
+	}()
+	return s, nil
+}
+
+// open 打开日志文件，并根据文件的修改时间计算下一次按照时间切割的时间，调用方需要持有锁
+// 这样进程重启之后，上一个周期留下的文件在第一次写入时就会被切割
+func (s *RotateSink) open() error {
+	f, err := NewFileSink(s.file)
+	if err != nil {
+		return err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return err
+	}
+	s.f, s.size = f, info.Size()
+	from := s.now()
+	if s.size > 0 {
+		from = info.ModTime()
+	}
+	s.next = nextRotation(from, s.opts.Interval)
+	return nil
+}
+
+// nextRotation 返回t之后下一个切割的时间点，使用t所在的时区
+func nextRotation(t time.Time, interval RotateInterval) time.Time {
+	switch interval {
+	case RotateHourly:
+		return time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+	case RotateDaily:
+		return time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+	}
+	return time.Time{}
+}
+
+func (s *RotateSink) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return 0, os.ErrClosed
+	}
+	now := s.now()
+	if s.f != nil && s.size > 0 && (!s.next.IsZero() && !now.Before(s.next) ||
+		s.opts.MaxSize > 0 && s.size+int64(len(p)) > s.opts.MaxSize) {
+		if err := s.rotate(now); err != nil {
+			fmt.Fprintf(os.Stderr, "log: rotate %s: %v\n", s.file, err)
+		}
+	}
+	if s.f == nil {
+		if err := s.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := s.f.Write(p)
+	s.size += int64(n)
+	return n, err
+}
+
+// rotate 把当前的日志文件重命名为旧文件，打开新的日志文件，调用方需要持有锁
+// 重命名失败时重新打开原来的文件，s.f只有在文件都打不开时才为nil
+func (s *RotateSink) rotate(now time.Time) error {
+	err := s.f.Close()
+	s.f = nil
+	if err == nil {
+		err = s.rename(s.file, s.backupName(now))
+	}
+	if err != nil {
+		return errors.Join(err, s.open())
+	}
+	if err := s.open(); err != nil {
+		return err
+	}
+	// 文件是新创建的，下一次切割从现在开始计算
+	s.next = nextRotation(now, s.opts.Interval)
+	select {
+	case s.mill <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// backupName 返回在时间t切割出来的旧文件的文件名，已经存在同名的旧文件时加上序号
+func (s *RotateSink) backupName(t time.Time) string {
+	ext := filepath.Ext(s.file)
+	base := strings.TrimSuffix(s.file, ext) + "-" + t.Local().Format(backupTimeFormat)
+	name := base + ext
+	for seq := 1; exists(name) || exists(name+".gz"); seq++ {
+		name = base + "-" + strconv.Itoa(seq) + ext
+	}
+	return name
+}
+
+// exists 文件是否存在
+func exists(name string) bool {
+	_, err := os.Lstat(name)
+	return err == nil
+}
+
+// Reopen 关闭并重新打开日志文件，日志文件被外部移走之后，之后的日志写入新创建的文件
+func (s *RotateSink) Reopen() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.closed {
+		return os.ErrClosed
+	}
+	if s.f != nil {
+		if err := s.f.Close(); err != nil {
+			return err
+		}
+	}
+	return s.open()
+}
+
+// Close 关闭日志文件，并等待后台的压缩和清理完成
+func (s *RotateSink) Close() error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
+	s.closed = true
+	signal.Stop(s.signals)
+	close(s.signals)
+	close(s.mill)
+	var err error
+	if s.f != nil {
+		err = s.f.Close()
+	}
+	s.lock.Unlock()
+	<-s.millDone
+	return err
+}
+
+// backup 是一个切割出来的旧文件
+type backup struct {
+	name string
+	at   time.Time
+	// seq 同一毫秒内切割的序号
+	seq int
+}
+
+// backups 按照切割时间从新到旧返回所有的旧文件
+func (s *RotateSink) backups() ([]backup, error) {
+	dir := filepath.Dir(s.file)
+	ext := filepath.Ext(s.file)
+	prefix := strings.TrimSuffix(filepath.Base(s.file), ext) + "-"
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var backups []backup
+	for _, entry := range entries {
+		name := entry.Name()
+		stamp := strings.TrimSuffix(strings.TrimSuffix(name, ".gz"), ext)
+		if entry.IsDir() || !strings.HasPrefix(stamp, prefix) || !strings.HasSuffix(strings.TrimSuffix(name, ".gz"), ext) {
+			continue
+		}
+		stamp, suffix, hasSeq := strings.Cut(strings.TrimPrefix(stamp, prefix), "-")
+		at, err := time.ParseInLocation(backupTimeFormat, stamp, time.Local)
+		if err != nil {
+			continue
+		}
+		var seq int
+		if hasSeq {
+			if seq, err = strconv.Atoi(suffix); err != nil || seq <= 0 {
+				continue
+			}
+		}
+		backups = append(backups, backup{name: filepath.Join(dir, name), at: at, seq: seq})
+	}
+	slices.SortFunc(backups, func(a, b backup) int {
+		if c := b.at.Compare(a.at); c != 0 {
+			return c
+		}
+		return b.seq - a.seq
+	})
+	return backups, nil
+}
+
+// millRun 在后台压缩和清理旧文件，直到Close
+func (s *RotateSink) millRun() {
+	defer close(s.millDone)
+	for range s.mill {
+		if err := s.millOnce(); err != nil {
+			fmt.Fprintf(os.Stderr, "log: rotate %s: %v\n", s.file, err)
+		}
+	}
+}
+
+// millOnce 删除超过数量或者过期的旧文件，压缩剩下的没有压缩的旧文件
+func (s *RotateSink) millOnce() error {
+	backups, err := s.backups()
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	var errs []error
+	for i, b := range backups {
+		if s.opts.MaxBackups > 0 && i >= s.opts.MaxBackups || s.opts.MaxAge > 0 && now.Sub(b.at) > s.opts.MaxAge {
+			if err := os.Remove(b.name); err != nil && !os.IsNotExist(err) {
+				errs = append(errs, err)
+			}
+			continue
+		}
+		if s.opts.Compress && !strings.HasSuffix(b.name, ".gz") {
+			if err := compress(b.name); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// compress 把文件压缩为同名的.gz文件，压缩成功之后删除原来的文件
+func compress(name string) error {
+	src, err := os.Open(name)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	tmp := name + ".gz.tmp"
+	dst, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	_, err = io.Copy(zw, src)
+	if closeErr := zw.Close(); err == nil {
+		err = closeErr
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp, name+".gz")
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	src.Close()
+	return os.Remove(name)
+}
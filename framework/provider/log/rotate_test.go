@@ -0,0 +1,178 @@
+package log
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeClock 是测试中可以手动推进的时钟
+type fakeClock struct {
+	now  time.Time
+	lock sync.Mutex
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Add(d time.Duration) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func write(t *testing.T, s *RotateSink, lines ...string) {
+	for _, line := range lines {
+		_, err := s.Write([]byte(line))
+		require.NoError(t, err)
+	}
+}
+
+func read(t *testing.T, name string) string {
+	content, err := os.ReadFile(name)
+	require.NoError(t, err)
+	return string(content)
+}
+
+// backupFiles 返回目录中除了file之外的所有文件名，按照名字排序
+func backupFiles(t *testing.T, file string) []string {
+	entries, err := os.ReadDir(filepath.Dir(file))
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		if entry.Name() != filepath.Base(file) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestRotateBySize(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	clock := &fakeClock{now: time.Date(2026, 1, 2, 10, 0, 0, 0, time.Local)}
+	s, err := newRotateSink(file, RotateOptions{MaxSize: 12}, clock.Now)
+	require.NoError(t, err)
+
+	write(t, s, "first-line\n")
+	clock.Add(time.Second)
+	// 写入之后会超过最大大小，先切割
+	write(t, s, "second-line\n")
+	clock.Add(time.Second)
+	write(t, s, "third-line\n")
+	require.NoError(t, s.Close())
+
+	assert.Equal(t, "third-line\n", read(t, file))
+	assert.Equal(t, []string{"app-20260102T100001.000.log", "app-20260102T100002.000.log"}, backupFiles(t, file))
+	assert.Equal(t, "first-line\n", read(t, filepath.Join(filepath.Dir(file), "app-20260102T100001.000.log")))
+
+	_, err = s.Write([]byte("closed\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestRotateSameMillisecond(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	clock := &fakeClock{now: time.Date(2026, 1, 2, 10, 0, 0, 0, time.Local)}
+	s, err := newRotateSink(file, RotateOptions{MaxSize: 12, MaxBackups: 2}, clock.Now)
+	require.NoError(t, err)
+
+	// 同一毫秒内切割多次，旧文件不能互相覆盖
+	write(t, s, "first-line\n", "second-line\n", "third-line\n")
+	require.NoError(t, s.Close())
+
+	dir := filepath.Dir(file)
+	assert.Equal(t, []string{"app-20260102T100000.000-1.log", "app-20260102T100000.000.log"}, backupFiles(t, file))
+	assert.Equal(t, "first-line\n", read(t, filepath.Join(dir, "app-20260102T100000.000.log")))
+	assert.Equal(t, "second-line\n", read(t, filepath.Join(dir, "app-20260102T100000.000-1.log")))
+
+	// 带序号的旧文件按照序号排序，序号大的更新
+	backups, err := s.backups()
+	require.NoError(t, err)
+	require.Len(t, backups, 2)
+	assert.Equal(t, 1, backups[0].seq)
+}
+
+func TestRotateFailureKeepsWriting(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	clock := &fakeClock{now: time.Date(2026, 1, 2, 10, 0, 0, 0, time.Local)}
+	s, err := newRotateSink(file, RotateOptions{MaxSize: 12}, clock.Now)
+	require.NoError(t, err)
+	defer s.Close()
+
+	s.rename = func(string, string) error { return os.ErrPermission }
+	write(t, s, "first-line\n")
+	clock.Add(time.Second)
+	// 切割失败时继续写入原来的文件
+	write(t, s, "second-line\n")
+	assert.Equal(t, "first-line\nsecond-line\n", read(t, file))
+	assert.Empty(t, backupFiles(t, file))
+
+	// 恢复之后下一次写入时重新切割
+	s.rename = os.Rename
+	clock.Add(time.Second)
+	write(t, s, "third-line\n")
+	assert.Equal(t, "third-line\n", read(t, file))
+	assert.Equal(t, []string{"app-20260102T100002.000.log"}, backupFiles(t, file))
+}
+
+func TestRotateByTime(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	clock := &fakeClock{now: time.Date(2026, 1, 2, 23, 30, 0, 0, time.Local)}
+	s, err := newRotateSink(file, RotateOptions{Interval: RotateDaily}, clock.Now)
+	require.NoError(t, err)
+
+	write(t, s, "day one\n")
+	clock.Add(20 * time.Minute)
+	write(t, s, "still day one\n")
+	clock.Add(20 * time.Minute)
+	write(t, s, "day two\n")
+	require.NoError(t, s.Close())
+
+	assert.Equal(t, "day two\n", read(t, file))
+	assert.Equal(t, []string{"app-20260103T001000.000.log"}, backupFiles(t, file))
+
+	assert.Equal(t, time.Date(2026, 1, 2, 11, 0, 0, 0, time.Local),
+		nextRotation(time.Date(2026, 1, 2, 10, 59, 59, 0, time.Local), RotateHourly))
+	_, err = NewRotateSink(file, RotateOptions{Interval: "weekly"})
+	assert.EqualError(t, err, `log: unknown rotate interval "weekly"`)
+}
+
+func TestRotateRetentionAndCompress(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.log")
+	clock := &fakeClock{now: time.Date(2026, 1, 10, 0, 0, 0, 0, time.Local)}
+	// 上次运行留下的旧文件，其中一个已经过期
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app-20260101T000000.000.log"), []byte("expired\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "app-20260109T000000.000.log"), []byte("yesterday\n"), 0o644))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "access.log"), []byte("other sink\n"), 0o644))
+
+	s, err := newRotateSink(file, RotateOptions{MaxSize: 1, MaxBackups: 2, MaxAge: 72 * time.Hour, Compress: true}, clock.Now)
+	require.NoError(t, err)
+	for _, line := range []string{"a\n", "b\n", "c\n"} {
+		clock.Add(time.Minute)
+		write(t, s, line)
+	}
+	require.NoError(t, s.Close())
+
+	// 保留最新的两个旧文件并压缩，其他的文件不受影响
+	assert.Equal(t, []string{"access.log", "app-20260110T000200.000.log.gz", "app-20260110T000300.000.log.gz"}, backupFiles(t, file))
+	f, err := os.Open(filepath.Join(dir, "app-20260110T000300.000.log.gz"))
+	require.NoError(t, err)
+	defer f.Close()
+	zr, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	content, err := io.ReadAll(zr)
+	require.NoError(t, err)
+	assert.Equal(t, "b\n", string(content))
+}
@@ -0,0 +1,34 @@
+//go:build unix
+
+package log
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateReopenOnSIGHUP(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "app.log")
+	s, err := NewRotateSink(file, RotateOptions{})
+	require.NoError(t, err)
+	defer s.Close()
+	write(t, s, "before\n")
+
+	// 模拟外部的logrotate移走日志文件之后发送SIGHUP
+	require.NoError(t, os.Rename(file, file+".1"))
+	require.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+	assert.Eventually(t, func() bool {
+		_, err := os.Stat(file)
+		return err == nil
+	}, time.Second, 10*time.Millisecond)
+	write(t, s, "after\n")
+
+	assert.Equal(t, "before\n", read(t, file+".1"))
+	assert.Equal(t, "after\n", read(t, file))
+}
@@ -0,0 +1,201 @@
+package log
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/RZXBxie/web_server/framework/gin"
+)
+
+// Config 日志服务的配置，一般放在配置服务的log配置项中
+type Config struct {
+	// Level 输出的最低日志级别，为空时使用info
+	Level string `yaml:"level"`
+	// Formatter 日志的格式，text 或者 json，为空时使用text
+	Formatter string `yaml:"formatter"`
+	// Sinks 日志的输出目标，为空并且没有指定其他输出目标时输出到控制台
+	Sinks []SinkConfig `yaml:"sinks"`
+}
+
+// LogService 是日志服务的实现，With 创建的日志服务和原来的日志服务共享 core
+type LogService struct {
+	contract.Log
+
+	core *core
+
+	// fields 这个日志服务固定带有的字段
+	fields map[string]interface{}
+}
+
+// core 是所有派生出来的日志服务共享的部分
+type core struct {
+	level     atomic.Int32
+	formatter Formatter
+	writers   []io.Writer
+
+	// unwatch 取消对配置中日志级别的订阅
+	unwatch func()
+
+	lock sync.Mutex
+}
+
+// NewLogService 初始化日志服务，参数为服务容器、日志配置和额外的输出目标
+// 日志配置为空并且绑定了配置服务时，使用配置服务中的log配置项
+func NewLogService(params ...interface{}) (interface{}, error) {
+	c := params[0].(framework.Container)
+	cfg := params[1].(Config)
+	extra := params[2].([]io.Writer)
+
+	var config contract.Config
+	if c.IsBind(contract.ConfigKey) {
+		var err error
+		if config, err = framework.MakeAs[contract.Config](c, contract.ConfigKey); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Level == "" && cfg.Formatter == "" && len(cfg.Sinks) == 0 && config != nil && config.IsExist("log") {
+		if err := config.Load("log", &cfg); err != nil {
+			return nil, fmt.Errorf("log: load config: %w", err)
+		}
+	}
+
+	level := contract.InfoLevel
+	if cfg.Level != "" {
+		var err error
+		if level, err = contract.ParseLogLevel(cfg.Level); err != nil {
+			return nil, err
+		}
+	}
+	formatter, err := formatterOf(cfg.Formatter)
+	if err != nil {
+		return nil, err
+	}
+
+	folder := ""
+	if c.IsBind(contract.AppKey) {
+		if app, err := framework.MakeAs[contract.App](c, contract.AppKey); err == nil {
+			folder = app.LogFolder()
+		}
+	}
+	writers := make([]io.Writer, 0, len(cfg.Sinks)+len(extra))
+	for _, sink := range cfg.Sinks {
+		writer, err := sink.Open(folder)
+		if err != nil {
+			closeWriters(writers)
+			return nil, err
+		}
+		writers = append(writers, writer)
+	}
+	writers = append(writers, extra...)
+	if len(writers) == 0 {
+		writers = append(writers, os.Stdout)
+	}
+
+	core := &core{formatter: formatter, writers: writers}
+	core.level.Store(int32(level))
+	// 配置中的日志级别变化时跟着变化，不需要重启
+	if config != nil {
+		core.unwatch = config.Watch("log.level", func(changes []contract.ConfigChange) {
+			if level, err := contract.ParseLogLevel(config.GetString("log.level")); err == nil {
+				core.level.Store(int32(level))
+			}
+		})
+	}
+	return &LogService{core: core}, nil
+}
+
+func (s *LogService) Debug(ctx context.Context, msg string, fields map[string]interface{}) {
+	s.log(ctx, contract.DebugLevel, msg, fields)
+}
+
+func (s *LogService) Info(ctx context.Context, msg string, fields map[string]interface{}) {
+	s.log(ctx, contract.InfoLevel, msg, fields)
+}
+
+func (s *LogService) Warn(ctx context.Context, msg string, fields map[string]interface{}) {
+	s.log(ctx, contract.WarnLevel, msg, fields)
+}
+
+func (s *LogService) Error(ctx context.Context, msg string, fields map[string]interface{}) {
+	s.log(ctx, contract.ErrorLevel, msg, fields)
+}
+
+func (s *LogService) With(fields map[string]interface{}) contract.Log {
+	merged := maps.Clone(s.fields)
+	if merged == nil {
+		merged = make(map[string]interface{}, len(fields))
+	}
+	maps.Copy(merged, fields)
+	return &LogService{core: s.core, fields: merged}
+}
+
+func (s *LogService) SetLevel(level contract.LogLevel) {
+	s.core.level.Store(int32(level))
+}
+
+func (s *LogService) Level() contract.LogLevel {
+	return contract.LogLevel(s.core.level.Load())
+}
+
+// log 格式化日志并写入所有的输出目标，一个输出目标写入失败不影响其他输出目标
+// ctx中有请求ID时自动加上request_id字段，调用时传入的字段覆盖With设置的字段
+func (s *LogService) log(ctx context.Context, level contract.LogLevel, msg string, fields map[string]interface{}) {
+	if level < s.Level() {
+		return
+	}
+	entry := contract.LogEntry{Level: level, Time: time.Now(), Msg: msg, Fields: s.fields}
+	requestID := gin.RequestIDFromContext(ctx)
+	if len(fields) > 0 || requestID != "" {
+		entry.Fields = make(map[string]interface{}, len(s.fields)+len(fields)+1)
+		if requestID != "" {
+			entry.Fields[gin.RequestIDKey] = requestID
+		}
+		maps.Copy(entry.Fields, s.fields)
+		maps.Copy(entry.Fields, fields)
+	}
+	content, err := s.core.formatter(entry)
+	if err != nil {
+		content = []byte(fmt.Sprintf("%s [error] log: format %q: %v\n", entry.Time.Format(timeFormat), msg, err))
+	}
+
+	s.core.lock.Lock()
+	defer s.core.lock.Unlock()
+	for _, writer := range s.core.writers {
+		_, _ = writer.Write(content)
+	}
+}
+
+// Stop 服务容器关闭时关闭所有的日志文件
+func (s *LogService) Stop(ctx context.Context) error {
+	s.core.lock.Lock()
+	defer s.core.lock.Unlock()
+	if s.core.unwatch != nil {
+		s.core.unwatch()
+	}
+	err := closeWriters(s.core.writers)
+	s.core.writers = nil
+	return err
+}
+
+// closeWriters 关闭实现了io.Closer的输出目标，标准输出和标准错误不关闭
+func closeWriters(writers []io.Writer) error {
+	var errs []error
+	for _, writer := range writers {
+		if writer == os.Stdout || writer == os.Stderr {
+			continue
+		}
+		if closer, ok := writer.(io.Closer); ok {
+			errs = append(errs, closer.Close())
+		}
+	}
+	return errors.Join(errs...)
+}
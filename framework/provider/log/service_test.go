@@ -0,0 +1,125 @@
+package log
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/RZXBxie/web_server/framework/gin"
+	"github.com/RZXBxie/web_server/framework/provider/app"
+	"github.com/RZXBxie/web_server/framework/provider/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newLog(t *testing.T, c framework.Container, sp *LogProvider) contract.Log {
+	if c == nil {
+		c = framework.NewContainer()
+	}
+	require.NoError(t, c.Bind(sp))
+	t.Cleanup(func() { _ = c.Shutdown(context.Background()) })
+	return framework.MustMakeAs[contract.Log](c, contract.LogKey)
+}
+
+func TestLogLevelsAndFields(t *testing.T) {
+	sink := NewMemorySink(10)
+	logger := newLog(t, nil, &LogProvider{Config: Config{Level: "info"}, Writers: []io.Writer{sink}})
+
+	logger.Debug(context.Background(), "hidden", nil)
+	logger.Info(context.Background(), "user login", map[string]interface{}{"user": "bob", "cost": time.Second})
+	scoped := logger.With(map[string]interface{}{"module": "order", "user": "alice"})
+	scoped.Warn(context.Background(), "slow query", map[string]interface{}{"user": "carol", "sql": "select 1"})
+	scoped.Error(context.Background(), "failed", map[string]interface{}{"err": errors.New("db down")})
+
+	lines := sink.Lines()
+	require.Len(t, lines, 3)
+	assert.Regexp(t, `^\S+ \[info\] user login cost=1s user=bob\n$`, lines[0])
+	assert.Regexp(t, `^\S+ \[warn\] slow query module=order sql="select 1" user=carol\n$`, lines[1])
+	assert.Regexp(t, `^\S+ \[error\] failed err="db down" module=order user=alice\n$`, lines[2])
+
+	// With派生的日志服务共享日志级别
+	scoped.SetLevel(contract.DebugLevel)
+	assert.Equal(t, contract.DebugLevel, logger.Level())
+	logger.Debug(context.Background(), "visible", nil)
+	assert.Len(t, sink.Lines(), 4)
+}
+
+func TestLogRequestID(t *testing.T) {
+	sink := NewMemorySink(10)
+	logger := newLog(t, nil, &LogProvider{Writers: []io.Writer{sink}})
+	ctx := gin.WithRequestID(context.Background(), "req-1")
+	logger.Info(ctx, "with request id", nil)
+	logger.With(map[string]interface{}{"module": "order"}).Info(ctx, "with fields", map[string]interface{}{"n": 1})
+	logger.Info(context.Background(), "without request id", nil)
+
+	lines := sink.Lines()
+	require.Len(t, lines, 3)
+	assert.Regexp(t, `\[info\] with request id request_id=req-1\n$`, lines[0])
+	assert.Regexp(t, `\[info\] with fields module=order n=1 request_id=req-1\n$`, lines[1])
+	assert.Regexp(t, `\[info\] without request id\n$`, lines[2])
+}
+
+func TestLogJsonFormatter(t *testing.T) {
+	sink := NewMemorySink(10)
+	logger := newLog(t, nil, &LogProvider{Config: Config{Formatter: "json"}, Writers: []io.Writer{sink}})
+	logger.Info(context.Background(), "hello", map[string]interface{}{"n": 1, "err": errors.New("boom"), "msg": "ignored"})
+
+	var data map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(sink.Lines()[0]), &data))
+	assert.Equal(t, "info", data["level"])
+	assert.Equal(t, "hello", data["msg"])
+	assert.Equal(t, "boom", data["err"])
+	assert.EqualValues(t, 1, data["n"])
+	assert.NotEmpty(t, data["time"])
+}
+
+func TestLogInvalidConfig(t *testing.T) {
+	c := framework.NewContainer()
+	assert.EqualError(t, c.Bind(&LogProvider{Config: Config{Level: "verbose"}}), `log: unknown level "verbose"`)
+	assert.EqualError(t, c.Bind(&LogProvider{Config: Config{Formatter: "xml"}}), `log: unknown formatter "xml"`)
+	assert.EqualError(t, c.Bind(&LogProvider{Config: Config{Sinks: []SinkConfig{{Type: "kafka"}}}}), `log: unknown sink type "kafka"`)
+}
+
+func TestMemorySinkRing(t *testing.T) {
+	sink := NewMemorySink(3)
+	for i := 1; i <= 5; i++ {
+		fmt.Fprintf(sink, "%d", i)
+	}
+	assert.Equal(t, []string{"3", "4", "5"}, sink.Lines())
+}
+
+func TestLogFromConfig(t *testing.T) {
+	base := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(base, "config"), 0o755))
+	logYaml := filepath.Join(base, "config", "log.yaml")
+	require.NoError(t, os.WriteFile(logYaml, []byte("level: warn\nsinks:\n  - type: file\n    file: app.log\n"), 0o644))
+
+	mode := gin.Mode()
+	t.Cleanup(func() { gin.SetMode(mode) })
+	c := framework.NewContainer()
+	require.NoError(t, c.Bind(&app.AppProvider{BaseFolder: base, Args: []string{"--env=testing"}}))
+	require.NoError(t, c.Bind(&config.ConfigProvider{}))
+	logger := newLog(t, c, &LogProvider{})
+	assert.Equal(t, contract.WarnLevel, logger.Level())
+
+	logger.Info(context.Background(), "hidden", nil)
+	logger.Warn(context.Background(), "disk almost full", nil)
+	content, err := os.ReadFile(filepath.Join(base, "storage", "log", "app.log"))
+	require.NoError(t, err)
+	assert.Equal(t, 1, strings.Count(string(content), "\n"))
+	assert.Contains(t, string(content), "[warn] disk almost full")
+
+	// 配置中的日志级别修改之后重新加载即可生效
+	require.NoError(t, os.WriteFile(logYaml, []byte("level: debug\nsinks:\n  - type: file\n    file: app.log\n"), 0o644))
+	require.NoError(t, framework.MustMakeAs[contract.Config](c, contract.ConfigKey).Reload())
+	assert.Equal(t, contract.DebugLevel, logger.Level())
+}
@@ -0,0 +1,105 @@
+package log
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// SinkConfig 描述一个日志的输出目标
+type SinkConfig struct {
+	// Type 输出目标的类型，console、file、rotate、memory 之一
+	Type string `yaml:"type"`
+	// File file和rotate类型输出的文件，相对路径相对于应用环境服务的日志目录
+	File string `yaml:"file"`
+	// MaxSize rotate类型单个文件的最大大小，单位为MB，为0时不按照大小切割
+	MaxSize int `yaml:"max_size"`
+	// Interval rotate类型按照时间切割的周期，hourly 或者 daily，为空时不按照时间切割
+	Interval string `yaml:"interval"`
+	// MaxBackups rotate类型最多保留的旧文件的数量，为0时不限制
+	MaxBackups int `yaml:"max_backups"`
+	// MaxAge rotate类型旧文件保留的时间，例如 168h，为0时不限制
+	MaxAge time.Duration `yaml:"max_age"`
+	// Compress rotate类型是否在后台把切割出来的旧文件压缩为gzip
+	Compress bool `yaml:"compress"`
+	// Size memory类型保留的日志条数，为0时使用1000
+	Size int `yaml:"size"`
+}
+
+// Open 根据配置创建输出目标，folder 是相对路径的日志文件所在的目录
+// 除了日志服务，也可以用来创建gin.LoggerConfig的Output，让访问日志和应用日志分别切割
+func (cfg SinkConfig) Open(folder string) (io.Writer, error) {
+	file := cfg.File
+	if file != "" && !filepath.IsAbs(file) {
+		file = filepath.Join(folder, file)
+	}
+	switch cfg.Type {
+	case "", "console":
+		return os.Stdout, nil
+	case "file":
+		return NewFileSink(file)
+	case "rotate":
+		return NewRotateSink(file, RotateOptions{
+			MaxSize:    int64(cfg.MaxSize) << 20,
+			Interval:   RotateInterval(cfg.Interval),
+			MaxBackups: cfg.MaxBackups,
+			MaxAge:     cfg.MaxAge,
+			Compress:   cfg.Compress,
+		})
+	case "memory":
+		return NewMemorySink(cfg.Size), nil
+	}
+	return nil, fmt.Errorf("log: unknown sink type %q", cfg.Type)
+}
+
+// NewFileSink 以追加的方式打开日志文件，目录不存在时创建
+func NewFileSink(file string) (*os.File, error) {
+	if file == "" {
+		return nil, fmt.Errorf("log: file sink requires a file")
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0o755); err != nil {
+		return nil, err
+	}
+	return os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+}
+
+// MemorySink 在内存中保留最近的日志，超过容量时覆盖最旧的日志，一般用于测试和调试
+type MemorySink struct {
+	lines []string
+	next  int
+	full  bool
+	lock  sync.Mutex
+}
+
+// NewMemorySink 创建保留最近size条日志的输出目标，size为0时使用1000
+func NewMemorySink(size int) *MemorySink {
+	if size <= 0 {
+		size = 1000
+	}
+	return &MemorySink{lines: make([]string, size)}
+}
+
+// Write 每次写入作为一条日志
+func (s *MemorySink) Write(p []byte) (int, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.lines[s.next] = string(p)
+	s.next = (s.next + 1) % len(s.lines)
+	if s.next == 0 {
+		s.full = true
+	}
+	return len(p), nil
+}
+
+// Lines 按照从旧到新的顺序返回保留的日志
+func (s *MemorySink) Lines() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if !s.full {
+		return append([]string(nil), s.lines[:s.next]...)
+	}
+	return append(append([]string(nil), s.lines[s.next:]...), s.lines[:s.next]...)
+}
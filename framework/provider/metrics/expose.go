@@ -0,0 +1,158 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ContentType Prometheus文本格式的Content-Type
+const ContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// expositionWriter 以Prometheus的文本格式输出，记录写入的字节数和第一个错误
+type expositionWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (ew *expositionWriter) printf(format string, args ...interface{}) {
+	if ew.err != nil {
+		return
+	}
+	n, err := fmt.Fprintf(ew.w, format, args...)
+	ew.n += int64(n)
+	ew.err = err
+}
+
+// header 输出指标的HELP和TYPE
+func (ew *expositionWriter) header(name, help, typ string) {
+	ew.printf("# HELP %s %s\n# TYPE %s %s\n", name, helpEscaper.Replace(help), name, typ)
+}
+
+// sample 输出一个值，labels和values一一对应，le不为空时追加直方图的le标签
+func (ew *expositionWriter) sample(name string, labels, values []string, le string, v float64) {
+	var b strings.Builder
+	b.WriteString(name)
+	if len(labels) > 0 || le != "" {
+		b.WriteByte('{')
+		for i, label := range labels {
+			writeLabel(&b, i, label, values[i])
+		}
+		if le != "" {
+			writeLabel(&b, len(labels), "le", le)
+		}
+		b.WriteByte('}')
+	}
+	ew.printf("%s %s\n", b.String(), formatFloat(v))
+}
+
+// writeLabel 输出第i个标签
+func writeLabel(b *strings.Builder, i int, label, value string) {
+	if i > 0 {
+		b.WriteByte(',')
+	}
+	b.WriteString(label)
+	b.WriteString(`="`)
+	b.WriteString(labelEscaper.Replace(value))
+	b.WriteByte('"')
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	labelEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+// formatFloat 按照文本格式的要求输出浮点数
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// write 输出一个指标的所有值，按照标签值排序，保证每次输出的顺序相同
+func (f *family) write(ew *expositionWriter) {
+	if f.fn != nil {
+		ew.header(f.name, f.help, f.typ)
+		ew.sample(f.name, nil, nil, "", f.fn())
+		return
+	}
+
+	f.lock.RLock()
+	all := make([]*series, 0, len(f.series))
+	for _, se := range f.series {
+		all = append(all, se)
+	}
+	f.lock.RUnlock()
+	if len(all) == 0 {
+		return
+	}
+	slices.SortFunc(all, func(a, b *series) int {
+		return slices.Compare(a.values, b.values)
+	})
+
+	ew.header(f.name, f.help, f.typ)
+	for _, se := range all {
+		if f.typ != typeHistogram {
+			ew.sample(f.name, f.labels, se.values, "", se.value.Load())
+			continue
+		}
+		// 先读取总数，并发的观测可能让桶的累加值略大于总数，+Inf使用累加值保证单调
+		count := se.count.Load()
+		var cumulative uint64
+		for i, upper := range f.buckets {
+			cumulative += se.counts[i].Load()
+			ew.sample(f.name+"_bucket", f.labels, se.values, formatFloat(upper), float64(cumulative))
+		}
+		ew.sample(f.name+"_bucket", f.labels, se.values, "+Inf", float64(max(count, cumulative)))
+		ew.sample(f.name+"_sum", f.labels, se.values, "", se.value.Load())
+		ew.sample(f.name+"_count", f.labels, se.values, "", float64(max(count, cumulative)))
+	}
+}
+
+// writeRuntime 输出Go运行时和进程的指标，名字和Prometheus官方客户端保持一致
+func writeRuntime(ew *expositionWriter, start time.Time) {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+
+	gauge := func(name, help string, v float64) {
+		ew.header(name, help, typeGauge)
+		ew.sample(name, nil, nil, "", v)
+	}
+	counter := func(name, help string, v float64) {
+		ew.header(name, help, typeCounter)
+		ew.sample(name, nil, nil, "", v)
+	}
+	gauge("go_goroutines", "Number of goroutines that currently exist.", float64(runtime.NumGoroutine()))
+	ew.header("go_info", "Information about the Go environment.", typeGauge)
+	ew.sample("go_info", []string{"version"}, []string{runtime.Version()}, "", 1)
+	gauge("go_memstats_alloc_bytes", "Number of bytes allocated and still in use.", float64(ms.Alloc))
+	counter("go_memstats_alloc_bytes_total", "Total number of bytes allocated, even if freed.", float64(ms.TotalAlloc))
+	gauge("go_memstats_heap_inuse_bytes", "Number of heap bytes that are in use.", float64(ms.HeapInuse))
+	gauge("go_memstats_heap_objects", "Number of allocated objects.", float64(ms.HeapObjects))
+	gauge("go_memstats_sys_bytes", "Number of bytes obtained from system.", float64(ms.Sys))
+	counter("go_memstats_mallocs_total", "Total number of mallocs.", float64(ms.Mallocs))
+	counter("go_memstats_frees_total", "Total number of frees.", float64(ms.Frees))
+	gauge("go_memstats_last_gc_time_seconds", "Number of seconds since 1970 of last garbage collection.", float64(ms.LastGC)/1e9)
+	counter("go_gc_cycles_total", "Number of completed GC cycles.", float64(ms.NumGC))
+	counter("go_gc_pause_seconds_total", "Total time spent in GC stop-the-world pauses.", float64(ms.PauseTotalNs)/1e9)
+	gauge("go_threads", "Number of OS threads created.", float64(threads()))
+	gauge("process_start_time_seconds", "Start time of the process since unix epoch in seconds.", float64(start.UnixNano())/1e9)
+}
+
+// threads 返回创建过的系统线程的数量
+func threads() int {
+	n, _ := runtime.ThreadCreateProfile(nil)
+	return n
+}
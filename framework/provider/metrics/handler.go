@@ -0,0 +1,30 @@
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/RZXBxie/web_server/framework/gin"
+)
+
+// Handler 以Prometheus的文本格式输出所有的指标，需要绑定 MetricsProvider
+func Handler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		metrics := framework.MustMakeAs[contract.Metrics](c, contract.MetricsKey)
+		c.ISetHeader("Content-Type", ContentType).ISetStatus(http.StatusOK)
+		_, _ = metrics.WriteTo(c.Writer)
+	}
+}
+
+// router 可以注册路由并且能够获取服务容器，*gin.Engine 和 *gin.RouterGroup 都满足
+type router interface {
+	gin.IRoutes
+	Container() framework.Container
+}
+
+// RegisterRoutes 把 Handler 挂载到指标服务配置的路径
+func RegisterRoutes(routes router) {
+	metrics := framework.MustMakeAs[contract.Metrics](routes.Container(), contract.MetricsKey)
+	routes.GET(metrics.Path(), Handler())
+}
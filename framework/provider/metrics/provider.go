@@ -0,0 +1,44 @@
+package metrics
+
+import (
+	"reflect"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+)
+
+// MetricsProvider 提供指标服务
+// 没有指定Config时，如果已经绑定了配置服务，使用配置服务中的metrics配置项
+type MetricsProvider struct {
+	Config
+}
+
+// Name 将服务对应的字符串凭证返回
+func (sp *MetricsProvider) Name() string {
+	return contract.MetricsKey
+}
+
+// Contract 声明服务实例需要实现contract.Metrics接口
+func (sp *MetricsProvider) Contract() reflect.Type {
+	return framework.ContractOf[contract.Metrics]()
+}
+
+// Register 注册指标服务的实例化方法
+func (sp *MetricsProvider) Register(c framework.Container) framework.NewInstance {
+	return NewMetricsService
+}
+
+// Boot 指标服务不需要准备工作
+func (sp *MetricsProvider) Boot(c framework.Container) error {
+	return nil
+}
+
+// Params 返回服务容器和指标配置
+func (sp *MetricsProvider) Params(c framework.Container) []interface{} {
+	return []interface{}{c, sp.Config}
+}
+
+// IsDefer 启动时就实例化，进程的启动时间和运行时指标从启动开始统计
+func (sp *MetricsProvider) IsDefer() bool {
+	return false
+}
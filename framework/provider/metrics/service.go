@@ -0,0 +1,306 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+)
+
+// Config 指标服务的配置，一般放在配置服务的metrics配置项中
+type Config struct {
+	// Path 暴露指标的路径，为空时使用/metrics
+	Path string `yaml:"path"`
+	// Buckets 直方图默认的桶，为空时使用 contract.DefaultBuckets
+	Buckets []float64 `yaml:"buckets"`
+	// DisableRuntime 是否不输出Go运行时的指标
+	DisableRuntime bool `yaml:"disable_runtime"`
+}
+
+// 指标的类型
+const (
+	typeCounter   = "counter"
+	typeGauge     = "gauge"
+	typeHistogram = "histogram"
+)
+
+// nameRegexp 合法的指标名和标签名
+var nameRegexp = regexp.MustCompile(`^[a-zA-Z_:][a-zA-Z0-9_:]*$`)
+
+// MetricsService 是指标服务的实现
+type MetricsService struct {
+	contract.Metrics
+
+	config Config
+
+	// start 服务的启动时间，作为进程的启动时间输出
+	start time.Time
+
+	families map[string]*family
+	lock     sync.RWMutex
+}
+
+// NewMetricsService 初始化指标服务，参数为服务容器和指标配置
+// 指标配置为空并且绑定了配置服务时，使用配置服务中的metrics配置项
+func NewMetricsService(params ...interface{}) (interface{}, error) {
+	c := params[0].(framework.Container)
+	cfg := params[1].(Config)
+
+	if cfg.Path == "" && len(cfg.Buckets) == 0 && !cfg.DisableRuntime && c.IsBind(contract.ConfigKey) {
+		config, err := framework.MakeAs[contract.Config](c, contract.ConfigKey)
+		if err != nil {
+			return nil, err
+		}
+		if config.IsExist("metrics") {
+			if err := config.Load("metrics", &cfg); err != nil {
+				return nil, fmt.Errorf("metrics: load config: %w", err)
+			}
+		}
+	}
+	if cfg.Path == "" {
+		cfg.Path = "/metrics"
+	}
+	if len(cfg.Buckets) == 0 {
+		cfg.Buckets = contract.DefaultBuckets
+	}
+	if err := checkBuckets(cfg.Buckets); err != nil {
+		return nil, err
+	}
+	return &MetricsService{config: cfg, start: time.Now(), families: make(map[string]*family)}, nil
+}
+
+// checkBuckets 桶的上界需要严格递增
+func checkBuckets(buckets []float64) error {
+	for i := 1; i < len(buckets); i++ {
+		if buckets[i] <= buckets[i-1] {
+			return fmt.Errorf("metrics: buckets must be in increasing order, got %v", buckets)
+		}
+	}
+	return nil
+}
+
+func (s *MetricsService) Counter(name, help string, labels ...string) contract.CounterVec {
+	return counterVec{s.register(name, help, typeCounter, nil, labels, nil)}
+}
+
+func (s *MetricsService) Gauge(name, help string, labels ...string) contract.GaugeVec {
+	return gaugeVec{s.register(name, help, typeGauge, nil, labels, nil)}
+}
+
+func (s *MetricsService) Histogram(name, help string, buckets []float64, labels ...string) contract.HistogramVec {
+	if len(buckets) == 0 {
+		buckets = s.config.Buckets
+	}
+	if err := checkBuckets(buckets); err != nil {
+		panic(err)
+	}
+	return histogramVec{s.register(name, help, typeHistogram, buckets, labels, nil)}
+}
+
+func (s *MetricsService) GaugeFunc(name, help string, fn func() float64) {
+	s.register(name, help, typeGauge, nil, nil, fn)
+}
+
+func (s *MetricsService) Path() string {
+	return s.config.Path
+}
+
+// register 注册指标，已经注册过时返回已经注册的指标
+func (s *MetricsService) register(name, help, typ string, buckets []float64, labels []string, fn func() float64) *family {
+	if !nameRegexp.MatchString(name) {
+		panic(fmt.Sprintf("metrics: invalid metric name %q", name))
+	}
+	for _, label := range labels {
+		if !nameRegexp.MatchString(label) || strings.HasPrefix(label, "__") || label == "le" {
+			panic(fmt.Sprintf("metrics: invalid label name %q for %s", label, name))
+		}
+	}
+
+	s.lock.RLock()
+	f, ok := s.families[name]
+	s.lock.RUnlock()
+	if !ok {
+		s.lock.Lock()
+		if f, ok = s.families[name]; !ok {
+			f = &family{
+				name:    name,
+				help:    help,
+				typ:     typ,
+				labels:  slices.Clone(labels),
+				buckets: slices.Clone(buckets),
+				fn:      fn,
+				series:  make(map[string]*series),
+			}
+			s.families[name] = f
+		}
+		s.lock.Unlock()
+	}
+	if ok && (f.typ != typ || !slices.Equal(f.labels, labels) || (f.fn == nil) != (fn == nil) ||
+		typ == typeHistogram && !slices.Equal(f.buckets, buckets)) {
+		panic(fmt.Sprintf("metrics: %s is already registered as a %s with labels %v", name, f.typ, f.labels))
+	}
+	return f
+}
+
+// family 是同一个名字的所有指标
+type family struct {
+	name    string
+	help    string
+	typ     string
+	labels  []string
+	buckets []float64
+	// fn 不为空时是GaugeFunc
+	fn func() float64
+
+	// series 按照标签值区分的指标，key为用\xff连接的标签值
+	series map[string]*series
+	lock   sync.RWMutex
+}
+
+// with 获取标签值为values的指标，不存在时创建
+func (f *family) with(values []string) *series {
+	if len(values) != len(f.labels) {
+		panic(fmt.Sprintf("metrics: %s expects %d label values, got %d", f.name, len(f.labels), len(values)))
+	}
+	key := strings.Join(values, "\xff")
+	f.lock.RLock()
+	se, ok := f.series[key]
+	f.lock.RUnlock()
+	if ok {
+		return se
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if se, ok = f.series[key]; !ok {
+		se = &series{values: slices.Clone(values)}
+		if f.typ == typeHistogram {
+			se.counts = make([]atomic.Uint64, len(f.buckets))
+		}
+		f.series[key] = se
+	}
+	return se
+}
+
+// series 是一组标签值对应的指标
+// 计数器和Gauge只使用value；直方图的counts是每个桶的观测次数(不累加)，value是观测值的和
+type series struct {
+	values []string
+	value  atomicFloat
+	counts []atomic.Uint64
+	count  atomic.Uint64
+}
+
+// atomicFloat 支持原子操作的float64
+type atomicFloat struct {
+	bits atomic.Uint64
+}
+
+func (a *atomicFloat) Load() float64 {
+	return math.Float64frombits(a.bits.Load())
+}
+
+func (a *atomicFloat) Store(v float64) {
+	a.bits.Store(math.Float64bits(v))
+}
+
+func (a *atomicFloat) Add(delta float64) {
+	for {
+		old := a.bits.Load()
+		if a.bits.CompareAndSwap(old, math.Float64bits(math.Float64frombits(old)+delta)) {
+			return
+		}
+	}
+}
+
+type counterVec struct{ f *family }
+
+func (v counterVec) With(values ...string) contract.Counter {
+	return counter{v.f.with(values)}
+}
+
+type counter struct{ se *series }
+
+func (c counter) Inc() {
+	c.se.value.Add(1)
+}
+
+func (c counter) Add(delta float64) {
+	if delta < 0 {
+		panic("metrics: counter can not decrease")
+	}
+	c.se.value.Add(delta)
+}
+
+type gaugeVec struct{ f *family }
+
+func (v gaugeVec) With(values ...string) contract.Gauge {
+	return gauge{v.f.with(values)}
+}
+
+type gauge struct{ se *series }
+
+func (g gauge) Set(v float64) {
+	g.se.value.Store(v)
+}
+
+func (g gauge) Add(delta float64) {
+	g.se.value.Add(delta)
+}
+
+func (g gauge) Inc() {
+	g.se.value.Add(1)
+}
+
+func (g gauge) Dec() {
+	g.se.value.Add(-1)
+}
+
+type histogramVec struct{ f *family }
+
+func (v histogramVec) With(values ...string) contract.Histogram {
+	return histogram{v.f.buckets, v.f.with(values)}
+}
+
+type histogram struct {
+	buckets []float64
+	se      *series
+}
+
+func (h histogram) Observe(v float64) {
+	// 落在最后一个桶之外的观测值只计入+Inf
+	if i, _ := slices.BinarySearch(h.buckets, v); i < len(h.buckets) {
+		h.se.counts[i].Add(1)
+	}
+	h.se.value.Add(v)
+	h.se.count.Add(1)
+}
+
+// WriteTo 按照指标名的顺序输出，Go运行时的指标放在最后
+func (s *MetricsService) WriteTo(w io.Writer) (int64, error) {
+	s.lock.RLock()
+	families := make([]*family, 0, len(s.families))
+	for _, f := range s.families {
+		families = append(families, f)
+	}
+	s.lock.RUnlock()
+	slices.SortFunc(families, func(a, b *family) int {
+		return strings.Compare(a.name, b.name)
+	})
+
+	ew := &expositionWriter{w: w}
+	for _, f := range families {
+		f.write(ew)
+	}
+	if !s.config.DisableRuntime {
+		writeRuntime(ew, s.start)
+	}
+	return ew.n, ew.err
+}
@@ -0,0 +1,112 @@
+package metrics
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newMetrics(t *testing.T, cfg Config) contract.Metrics {
+	c := framework.NewContainer()
+	require.NoError(t, c.Bind(&MetricsProvider{Config: cfg}))
+	t.Cleanup(func() { _ = c.Shutdown(context.Background()) })
+	return framework.MustMakeAs[contract.Metrics](c, contract.MetricsKey)
+}
+
+func expose(t *testing.T, metrics contract.Metrics) string {
+	var b strings.Builder
+	n, err := metrics.WriteTo(&b)
+	require.NoError(t, err)
+	assert.Equal(t, int64(b.Len()), n)
+	return b.String()
+}
+
+func TestMetricsExposition(t *testing.T) {
+	metrics := newMetrics(t, Config{DisableRuntime: true})
+	requests := metrics.Counter("requests_total", "Total requests.", "method", "path")
+	requests.With("GET", "/a").Inc()
+	requests.With("GET", "/a").Add(2)
+	requests.With("POST", `/"b"`).Inc()
+	metrics.Gauge("temperature", "Current\ntemperature.").With().Set(-1.5)
+	metrics.GaugeFunc("answer", "The answer.", func() float64 { return 42 })
+	latency := metrics.Histogram("latency_seconds", "Latency.", []float64{0.1, 1}, "route")
+	for _, v := range []float64{0.05, 0.1, 0.5, 3} {
+		latency.With("/x").Observe(v)
+	}
+	// 没有值的指标不输出
+	metrics.Counter("unused_total", "Unused.")
+
+	assert.Equal(t, `# HELP answer The answer.
+# TYPE answer gauge
+answer 42
+# HELP latency_seconds Latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/x",le="0.1"} 2
+latency_seconds_bucket{route="/x",le="1"} 3
+latency_seconds_bucket{route="/x",le="+Inf"} 4
+latency_seconds_sum{route="/x"} 3.65
+latency_seconds_count{route="/x"} 4
+# HELP requests_total Total requests.
+# TYPE requests_total counter
+requests_total{method="GET",path="/a"} 3
+requests_total{method="POST",path="/\"b\""} 1
+# HELP temperature Current\ntemperature.
+# TYPE temperature gauge
+temperature -1.5
+`, expose(t, metrics))
+}
+
+func TestMetricsRegister(t *testing.T) {
+	metrics := newMetrics(t, Config{Path: "/internal/metrics", DisableRuntime: true})
+	assert.Equal(t, "/internal/metrics", metrics.Path())
+
+	// 重复注册返回同一个指标
+	metrics.Counter("hits_total", "Hits.", "route").With("/").Inc()
+	metrics.Counter("hits_total", "Hits.", "route").With("/").Inc()
+	assert.Contains(t, expose(t, metrics), `hits_total{route="/"} 2`)
+
+	assert.PanicsWithValue(t, `metrics: hits_total is already registered as a counter with labels [route]`, func() {
+		metrics.Gauge("hits_total", "Hits.", "route")
+	})
+	assert.Panics(t, func() { metrics.Counter("hits_total", "Hits.", "path") })
+	assert.Panics(t, func() { metrics.Counter("hits_total", "Hits.", "route").With("/", "GET") })
+	assert.Panics(t, func() { metrics.Counter("hits_total", "Hits.", "route").With("/").Add(-1) })
+	assert.Panics(t, func() { metrics.Counter("invalid-name", "Invalid.") })
+	assert.Panics(t, func() { metrics.Histogram("sizes", "Sizes.", []float64{1, 1}) })
+}
+
+func TestMetricsConcurrent(t *testing.T) {
+	metrics := newMetrics(t, Config{Buckets: []float64{1}, DisableRuntime: true})
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				metrics.Counter("ops_total", "Ops.", "kind").With("a").Inc()
+				metrics.Histogram("op_seconds", "Op latency.", nil).With().Observe(0.5)
+				_ = expose(t, metrics)
+			}
+		}()
+	}
+	wg.Wait()
+
+	out := expose(t, metrics)
+	assert.Contains(t, out, `ops_total{kind="a"} 5000`)
+	assert.Contains(t, out, `op_seconds_bucket{le="1"} 5000`)
+	assert.Contains(t, out, `op_seconds_sum 2500`)
+}
+
+func TestMetricsRuntime(t *testing.T) {
+	out := expose(t, newMetrics(t, Config{}))
+	assert.Contains(t, out, "# TYPE go_goroutines gauge\n")
+	assert.Contains(t, out, `go_info{version="`)
+	assert.Contains(t, out, "# TYPE go_gc_cycles_total counter\n")
+	assert.Contains(t, out, "process_start_time_seconds ")
+}
@@ -0,0 +1,45 @@
+package ratelimit
+
+import (
+	"reflect"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+)
+
+// RateLimitProvider 提供限流存储服务，默认使用进程内的 MemoryStore
+// 多个实例需要共享配额时，通过Store指定Redis等共享的存储
+type RateLimitProvider struct {
+	// Store 不为空时代替内存存储
+	Store contract.RateLimitStore
+}
+
+// Name 将服务对应的字符串凭证返回
+func (sp *RateLimitProvider) Name() string {
+	return contract.RateLimitKey
+}
+
+// Contract 声明服务实例需要实现contract.RateLimitStore接口
+func (sp *RateLimitProvider) Contract() reflect.Type {
+	return framework.ContractOf[contract.RateLimitStore]()
+}
+
+// Register 注册限流存储服务的实例化方法
+func (sp *RateLimitProvider) Register(c framework.Container) framework.NewInstance {
+	return NewRateLimitService
+}
+
+// Boot 限流存储服务不需要准备工作
+func (sp *RateLimitProvider) Boot(c framework.Container) error {
+	return nil
+}
+
+// Params 返回服务容器和指定的存储
+func (sp *RateLimitProvider) Params(c framework.Container) []interface{} {
+	return []interface{}{c, sp.Store}
+}
+
+// IsDefer 在第一个需要限流的请求到来时实例化
+func (sp *RateLimitProvider) IsDefer() bool {
+	return true
+}
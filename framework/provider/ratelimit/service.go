@@ -0,0 +1,184 @@
+package ratelimit
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework/contract"
+)
+
+// minSweepSize 内存存储中key的数量超过这个值之后才开始清理过期的key
+const minSweepSize = 1024
+
+// NewRateLimitService 初始化限流存储服务，参数为服务容器和指定的存储，没有指定存储时使用内存存储
+func NewRateLimitService(params ...interface{}) (interface{}, error) {
+	if store, ok := params[1].(contract.RateLimitStore); ok && store != nil {
+		return store, nil
+	}
+	return NewMemoryStore(), nil
+}
+
+// MemoryStore 把限流状态保存在进程内存中的存储，只在单个进程内有效
+// 不启动后台的goroutine，key的数量翻倍时顺带清理已经恢复了全部配额的key
+type MemoryStore struct {
+	contract.RateLimitStore
+
+	entries   map[string]*entry
+	nextSweep int
+	lock      sync.Mutex
+}
+
+// entry 一个key的限流状态，令牌桶使用tokens和last，滑动窗口使用windowStart、prev和curr
+type entry struct {
+	tokens float64
+	last   time.Time
+
+	windowStart time.Time
+	prev, curr  int
+
+	// expires 这个时间之后状态等同于新的key，可以清理
+	expires time.Time
+}
+
+// NewMemoryStore 创建内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*entry), nextSweep: minSweepSize}
+}
+
+func (s *MemoryStore) Allow(ctx context.Context, key string, policy contract.RateLimitPolicy, now time.Time) (contract.RateLimitResult, error) {
+	if err := policy.Validate(); err != nil {
+		return contract.RateLimitResult{}, err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	e, ok := s.entries[key]
+	if ok && !now.Before(e.expires) {
+		ok = false
+	}
+	if !ok {
+		s.sweep(now)
+		e = &entry{}
+		s.entries[key] = e
+	}
+	if policy.Algorithm == contract.SlidingWindow {
+		return e.slidingWindow(policy, now, !ok), nil
+	}
+	return e.tokenBucket(policy, now, !ok), nil
+}
+
+// Len 返回保存的key的数量，包括已经过期还没有清理的key
+func (s *MemoryStore) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.entries)
+}
+
+// sweep 清理过期的key，调用方需要持有锁
+func (s *MemoryStore) sweep(now time.Time) {
+	if len(s.entries) < s.nextSweep {
+		return
+	}
+	for key, e := range s.entries {
+		if !now.Before(e.expires) {
+			delete(s.entries, key)
+		}
+	}
+	s.nextSweep = max(2*len(s.entries), minSweepSize)
+}
+
+// tokenBucket 令牌以 Limit/Window 的速度补充，桶的容量为Burst，新的key桶是满的
+func (e *entry) tokenBucket(policy contract.RateLimitPolicy, now time.Time, fresh bool) contract.RateLimitResult {
+	capacity := float64(policy.Limit)
+	if policy.Burst > 0 {
+		capacity = float64(policy.Burst)
+	}
+	// 每纳秒补充的令牌数
+	rate := float64(policy.Limit) / float64(policy.Window)
+
+	if fresh {
+		e.tokens = capacity
+	} else if elapsed := now.Sub(e.last); elapsed > 0 {
+		e.tokens = math.Min(capacity, e.tokens+float64(elapsed)*rate)
+	}
+	if now.After(e.last) || fresh {
+		e.last = now
+	}
+
+	result := contract.RateLimitResult{Limit: int(capacity)}
+	if e.tokens >= 1 {
+		e.tokens--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = durationOf((1 - e.tokens) / rate)
+	}
+	result.Remaining = int(e.tokens)
+	result.Reset = durationOf((capacity - e.tokens) / rate)
+	e.expires = e.last.Add(result.Reset)
+	return result
+}
+
+// slidingWindow 按照Window对齐的窗口计数，最近Window之内的请求数估算为
+// 上一个窗口的计数 * 上一个窗口还在最近Window之内的比例 + 当前窗口的计数
+func (e *entry) slidingWindow(policy contract.RateLimitPolicy, now time.Time, fresh bool) contract.RateLimitResult {
+	window := policy.Window
+	start := now.Truncate(window)
+	switch {
+	case fresh:
+		e.windowStart, e.prev, e.curr = start, 0, 0
+	case start.After(e.windowStart):
+		if start.Sub(e.windowStart) == window {
+			e.prev = e.curr
+		} else {
+			e.prev = 0
+		}
+		e.windowStart, e.curr = start, 0
+	}
+	// 时钟回拨时按照窗口的开始计算
+	elapsed := max(now.Sub(e.windowStart), 0)
+	limit := float64(policy.Limit)
+	weight := 1 - float64(elapsed)/float64(window)
+	used := float64(e.prev)*weight + float64(e.curr)
+
+	result := contract.RateLimitResult{Limit: policy.Limit}
+	if used+1 <= limit {
+		e.curr++
+		used++
+		result.Allowed = true
+	} else {
+		result.RetryAfter = e.retryAfter(limit, window, elapsed)
+	}
+	result.Remaining = int(math.Max(0, limit-used))
+	end := e.windowStart.Add(window)
+	switch {
+	case e.curr > 0:
+		result.Reset = end.Add(window).Sub(now)
+	case e.prev > 0:
+		result.Reset = end.Sub(now)
+	}
+	e.expires = end.Add(window)
+	return result
+}
+
+// retryAfter 计算估算的请求数降到 limit-1 需要的时间
+func (e *entry) retryAfter(limit float64, window, elapsed time.Duration) time.Duration {
+	// 当前窗口之内，随着上一个窗口的权重降低就可以再次请求
+	if e.prev > 0 && float64(e.curr)+1 <= limit {
+		weight := (limit - float64(e.curr) - 1) / float64(e.prev)
+		return max(durationOf((1-weight)*float64(window))-elapsed, 0)
+	}
+	// 需要等到下一个窗口，当前窗口的计数成为上一个窗口的计数
+	wait := window - elapsed
+	if float64(e.curr)+1 > limit {
+		weight := (limit - 1) / float64(e.curr)
+		wait += durationOf((1 - weight) * float64(window))
+	}
+	return wait
+}
+
+// durationOf 把纳秒数向上取整为时间长度
+func durationOf(ns float64) time.Duration {
+	return time.Duration(math.Ceil(ns))
+}
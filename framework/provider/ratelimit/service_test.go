@@ -0,0 +1,124 @@
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestTokenBucket(t *testing.T) {
+	store := NewMemoryStore()
+	policy := contract.RateLimitPolicy{Limit: 2, Window: time.Second, Burst: 3}
+	ctx := context.Background()
+
+	// 新的key桶是满的，可以突发Burst个请求
+	for i := 2; i >= 0; i-- {
+		result, err := store.Allow(ctx, "a", policy, epoch)
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, i, result.Remaining)
+	}
+	result, err := store.Allow(ctx, "a", policy, epoch)
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 500*time.Millisecond, result.RetryAfter)
+	assert.Equal(t, 1500*time.Millisecond, result.Reset)
+
+	// 每500毫秒补充一个令牌
+	result, _ = store.Allow(ctx, "a", policy, epoch.Add(500*time.Millisecond))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	result, _ = store.Allow(ctx, "a", policy, epoch.Add(600*time.Millisecond))
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 400*time.Millisecond, result.RetryAfter)
+
+	// 其他的key不受影响
+	result, _ = store.Allow(ctx, "b", policy, epoch)
+	assert.True(t, result.Allowed)
+}
+
+func TestSlidingWindow(t *testing.T) {
+	store := NewMemoryStore()
+	policy := contract.RateLimitPolicy{Algorithm: contract.SlidingWindow, Limit: 4, Window: time.Minute}
+	ctx := context.Background()
+
+	for i := 3; i >= 0; i-- {
+		result, err := store.Allow(ctx, "a", policy, epoch.Add(30*time.Second))
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, i, result.Remaining)
+	}
+	result, _ := store.Allow(ctx, "a", policy, epoch.Add(45*time.Second))
+	assert.False(t, result.Allowed)
+	// 下一个窗口开始时上一个窗口的4个请求仍然全部计入，需要再等15秒权重才降到3/4
+	assert.Equal(t, 30*time.Second, result.RetryAfter)
+	assert.Equal(t, 75*time.Second, result.Reset)
+
+	// 下一个窗口过了一半，估算的请求数为 4*0.5 = 2
+	result, _ = store.Allow(ctx, "a", policy, epoch.Add(90*time.Second))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+	result, _ = store.Allow(ctx, "a", policy, epoch.Add(90*time.Second))
+	assert.True(t, result.Allowed)
+	result, _ = store.Allow(ctx, "a", policy, epoch.Add(90*time.Second))
+	assert.False(t, result.Allowed)
+	// 估算的请求数降到3需要上一个窗口的权重降到1/4
+	assert.Equal(t, 15*time.Second, result.RetryAfter)
+
+	// 两个窗口之后状态重置
+	result, _ = store.Allow(ctx, "a", policy, epoch.Add(3*time.Minute))
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 3, result.Remaining)
+}
+
+func TestInvalidPolicy(t *testing.T) {
+	store := NewMemoryStore()
+	_, err := store.Allow(context.Background(), "a", contract.RateLimitPolicy{Limit: 1}, epoch)
+	assert.Error(t, err)
+	_, err = store.Allow(context.Background(), "a", contract.RateLimitPolicy{Algorithm: "leaky", Limit: 1, Window: time.Second}, epoch)
+	assert.Error(t, err)
+}
+
+func TestSweep(t *testing.T) {
+	store := NewMemoryStore()
+	policy := contract.RateLimitPolicy{Limit: 10, Window: time.Second}
+	for i := 0; i < minSweepSize; i++ {
+		_, err := store.Allow(context.Background(), fmt.Sprint(i), policy, epoch)
+		require.NoError(t, err)
+	}
+	assert.Equal(t, minSweepSize, store.Len())
+	// 所有的key都已经恢复了全部配额，新的key触发清理
+	_, err := store.Allow(context.Background(), "new", policy, epoch.Add(time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.Len())
+}
+
+// failStore 模拟共享存储
+type failStore struct{}
+
+func (failStore) Allow(context.Context, string, contract.RateLimitPolicy, time.Time) (contract.RateLimitResult, error) {
+	return contract.RateLimitResult{}, fmt.Errorf("unavailable")
+}
+
+func TestProvider(t *testing.T) {
+	c := framework.NewContainer()
+	require.NoError(t, c.Bind(&RateLimitProvider{}))
+	store, err := framework.MakeAs[contract.RateLimitStore](c, contract.RateLimitKey)
+	require.NoError(t, err)
+	assert.IsType(t, &MemoryStore{}, store)
+
+	c = framework.NewContainer()
+	require.NoError(t, c.Bind(&RateLimitProvider{Store: failStore{}}))
+	store, err = framework.MakeAs[contract.RateLimitStore](c, contract.RateLimitKey)
+	require.NoError(t, err)
+	assert.Equal(t, failStore{}, store)
+}
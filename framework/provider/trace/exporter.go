@@ -0,0 +1,110 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework/contract"
+)
+
+// ExporterConfig 导出器的配置
+type ExporterConfig struct {
+	// Type 导出器的类型：none、stdout、memory、otlp，为空时使用none
+	Type string `yaml:"type"`
+	// Endpoint otlp导出器的collector地址，例如 http://localhost:4318，没有路径时使用/v1/traces
+	Endpoint string `yaml:"endpoint"`
+	// Headers otlp导出器请求collector时附加的请求头，例如鉴权信息
+	Headers map[string]string `yaml:"headers"`
+	// Timeout otlp导出器每次请求的超时时间，为0时使用10秒
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// Open 按照配置创建导出器
+func (cfg ExporterConfig) Open() (contract.SpanExporter, error) {
+	switch cfg.Type {
+	case "", "none":
+		return discardExporter{}, nil
+	case "stdout":
+		return NewStdoutExporter(os.Stdout), nil
+	case "memory":
+		return NewMemoryExporter(), nil
+	case "otlp":
+		return NewOTLPExporter(cfg.Endpoint, cfg.Headers, cfg.Timeout)
+	}
+	return nil, fmt.Errorf("trace: unknown exporter type %q", cfg.Type)
+}
+
+// discardExporter 丢弃所有的span，只传递调用链路
+type discardExporter struct{}
+
+func (discardExporter) ExportSpans(context.Context, []contract.SpanData) error { return nil }
+func (discardExporter) Shutdown(context.Context) error                         { return nil }
+
+// StdoutExporter 把每个span输出为一行json
+type StdoutExporter struct {
+	w    io.Writer
+	lock sync.Mutex
+}
+
+// NewStdoutExporter 创建输出到w的导出器，w一般是os.Stdout
+func NewStdoutExporter(w io.Writer) *StdoutExporter {
+	return &StdoutExporter{w: w}
+}
+
+func (e *StdoutExporter) ExportSpans(ctx context.Context, spans []contract.SpanData) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	encoder := json.NewEncoder(e.w)
+	for _, data := range spans {
+		if err := encoder.Encode(data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *StdoutExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// MemoryExporter 把span保存在内存中，用于测试
+type MemoryExporter struct {
+	spans []contract.SpanData
+	lock  sync.Mutex
+}
+
+// NewMemoryExporter 创建保存在内存中的导出器
+func NewMemoryExporter() *MemoryExporter {
+	return &MemoryExporter{}
+}
+
+func (e *MemoryExporter) ExportSpans(ctx context.Context, spans []contract.SpanData) error {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.spans = append(e.spans, spans...)
+	return nil
+}
+
+func (e *MemoryExporter) Shutdown(ctx context.Context) error {
+	return nil
+}
+
+// Spans 返回所有导出的span，按照导出的顺序
+func (e *MemoryExporter) Spans() []contract.SpanData {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return slices.Clone(e.spans)
+}
+
+// Reset 清空导出的span
+func (e *MemoryExporter) Reset() {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	e.spans = nil
+}
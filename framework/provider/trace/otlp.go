@@ -0,0 +1,184 @@
+package trace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework/contract"
+)
+
+// OTLPExporter 通过OTLP/HTTP把span以json编码发送给OpenTelemetry collector
+type OTLPExporter struct {
+	endpoint string
+	headers  map[string]string
+	client   *http.Client
+}
+
+// NewOTLPExporter 创建发送到endpoint的导出器，endpoint没有路径时使用/v1/traces
+func NewOTLPExporter(endpoint string, headers map[string]string, timeout time.Duration) (*OTLPExporter, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("trace: invalid otlp endpoint %q", endpoint)
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v1/traces"
+	}
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &OTLPExporter{endpoint: u.String(), headers: maps.Clone(headers), client: &http.Client{Timeout: timeout}}, nil
+}
+
+func (e *OTLPExporter) ExportSpans(ctx context.Context, spans []contract.SpanData) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(otlpRequest(spans))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("trace: export spans: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("trace: export spans: collector responded %s", resp.Status)
+	}
+	return nil
+}
+
+func (e *OTLPExporter) Shutdown(ctx context.Context) error {
+	e.client.CloseIdleConnections()
+	return nil
+}
+
+// 下面是OTLP/JSON中用到的结构，字段名和枚举值遵循OTLP的protobuf定义
+
+type otlpKeyValue struct {
+	Key   string                 `json:"key"`
+	Value map[string]interface{} `json:"value"`
+}
+
+type otlpEvent struct {
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Name         string         `json:"name"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	TraceState        string         `json:"traceState,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Events            []otlpEvent    `json:"events,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+var otlpKinds = map[contract.SpanKind]int{
+	contract.SpanKindInternal: 1,
+	contract.SpanKindServer:   2,
+	contract.SpanKindClient:   3,
+}
+
+var otlpStatusCodes = map[contract.SpanStatus]int{
+	contract.SpanStatusUnset: 0,
+	contract.SpanStatusOK:    1,
+	contract.SpanStatusError: 2,
+}
+
+// otlpRequest 按照服务名分组，生成ExportTraceServiceRequest
+func otlpRequest(spans []contract.SpanData) map[string]interface{} {
+	byService := make(map[string][]otlpSpan)
+	for _, data := range spans {
+		span := otlpSpan{
+			TraceID:           data.TraceID,
+			SpanID:            data.SpanID,
+			ParentSpanID:      data.ParentSpanID,
+			TraceState:        data.TraceState,
+			Name:              data.Name,
+			Kind:              otlpKinds[data.Kind],
+			StartTimeUnixNano: strconv.FormatInt(data.Start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(data.End.UnixNano(), 10),
+			Attributes:        otlpAttributes(data.Attributes),
+			Status:            otlpStatus{Code: otlpStatusCodes[data.Status], Message: data.StatusMessage},
+		}
+		for _, event := range data.Events {
+			span.Events = append(span.Events, otlpEvent{
+				TimeUnixNano: strconv.FormatInt(event.Time.UnixNano(), 10),
+				Name:         event.Name,
+				Attributes:   otlpAttributes(event.Attributes),
+			})
+		}
+		byService[data.Service] = append(byService[data.Service], span)
+	}
+
+	resourceSpans := make([]interface{}, 0, len(byService))
+	for _, service := range slices.Sorted(maps.Keys(byService)) {
+		resourceSpans = append(resourceSpans, map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": otlpAttributes(map[string]interface{}{"service.name": service}),
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]interface{}{"name": "github.com/RZXBxie/web_server/framework/provider/trace"},
+				"spans": byService[service],
+			}},
+		})
+	}
+	return map[string]interface{}{"resourceSpans": resourceSpans}
+}
+
+// otlpAttributes 按照key排序转换属性，整数按照OTLP/JSON的约定编码为字符串，不认识的类型转换为字符串
+func otlpAttributes(attrs map[string]interface{}) []otlpKeyValue {
+	if len(attrs) == 0 {
+		return nil
+	}
+	kvs := make([]otlpKeyValue, 0, len(attrs))
+	for _, key := range slices.Sorted(maps.Keys(attrs)) {
+		var value map[string]interface{}
+		switch v := attrs[key].(type) {
+		case string:
+			value = map[string]interface{}{"stringValue": v}
+		case bool:
+			value = map[string]interface{}{"boolValue": v}
+		case int:
+			value = map[string]interface{}{"intValue": strconv.Itoa(v)}
+		case int64:
+			value = map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			value = map[string]interface{}{"doubleValue": v}
+		default:
+			value = map[string]interface{}{"stringValue": fmt.Sprint(v)}
+		}
+		kvs = append(kvs, otlpKeyValue{Key: key, Value: value})
+	}
+	return kvs
+}
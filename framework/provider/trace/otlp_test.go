@@ -0,0 +1,89 @@
+package trace
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// collector 模拟OpenTelemetry collector的OTLP/HTTP接口
+type collector struct {
+	*httptest.Server
+	requests chan map[string]interface{}
+	headers  chan http.Header
+}
+
+func newCollector(t *testing.T, status int) *collector {
+	c := &collector{requests: make(chan map[string]interface{}, 10), headers: make(chan http.Header, 10)}
+	c.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v1/traces", r.URL.Path)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		body, _ := io.ReadAll(r.Body)
+		var req map[string]interface{}
+		assert.NoError(t, json.Unmarshal(body, &req))
+		c.requests <- req
+		c.headers <- r.Header
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(c.Close)
+	return c
+}
+
+func TestOTLPExporter(t *testing.T) {
+	col := newCollector(t, http.StatusOK)
+	tracer := newTrace(t, &TraceProvider{Config: Config{
+		ServiceName: "orders",
+		Exporter:    ExporterConfig{Type: "otlp", Endpoint: col.URL, Headers: map[string]string{"Authorization": "Bearer token"}},
+	}})
+
+	ctx, root := tracer.Start(context.Background(), "GET /orders/:id", contract.SpanKindServer)
+	root.SetAttributes(map[string]interface{}{"http.status_code": 500, "http.route": "/orders/:id"})
+	_, child := tracer.Start(ctx, "select", contract.SpanKindClient)
+	child.End()
+	root.SetStatus(contract.SpanStatusError, "500 Internal Server Error")
+	root.End()
+	require.NoError(t, tracer.Flush(context.Background()))
+
+	req := <-col.requests
+	assert.Equal(t, "Bearer token", (<-col.headers).Get("Authorization"))
+	resourceSpans := req["resourceSpans"].([]interface{})
+	require.Len(t, resourceSpans, 1)
+	resource := resourceSpans[0].(map[string]interface{})
+	assert.Equal(t, []interface{}{map[string]interface{}{"key": "service.name", "value": map[string]interface{}{"stringValue": "orders"}}},
+		resource["resource"].(map[string]interface{})["attributes"])
+	spans := resource["scopeSpans"].([]interface{})[0].(map[string]interface{})["spans"].([]interface{})
+	require.Len(t, spans, 2)
+
+	child0, root1 := spans[0].(map[string]interface{}), spans[1].(map[string]interface{})
+	assert.Equal(t, "select", child0["name"])
+	assert.Equal(t, float64(3), child0["kind"])
+	assert.Equal(t, root1["spanId"], child0["parentSpanId"])
+	assert.Equal(t, root.SpanContext().TraceID, root1["traceId"])
+	assert.Equal(t, float64(2), root1["kind"])
+	assert.Equal(t, map[string]interface{}{"code": float64(2), "message": "500 Internal Server Error"}, root1["status"])
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"key": "http.route", "value": map[string]interface{}{"stringValue": "/orders/:id"}},
+		map[string]interface{}{"key": "http.status_code", "value": map[string]interface{}{"intValue": "500"}},
+	}, root1["attributes"])
+	assert.NotEmpty(t, root1["startTimeUnixNano"])
+}
+
+func TestOTLPExporterErrors(t *testing.T) {
+	col := newCollector(t, http.StatusServiceUnavailable)
+	exporter, err := NewOTLPExporter(col.URL, nil, 0)
+	require.NoError(t, err)
+	err = exporter.ExportSpans(context.Background(), []contract.SpanData{{Name: "span", TraceID: "1", SpanID: "2"}})
+	assert.EqualError(t, err, "trace: export spans: collector responded 503 Service Unavailable")
+
+	_, err = NewOTLPExporter("localhost:4318", nil, 0)
+	assert.EqualError(t, err, `trace: invalid otlp endpoint "localhost:4318"`)
+	_, err = ExporterConfig{Type: "zipkin"}.Open()
+	assert.EqualError(t, err, `trace: unknown exporter type "zipkin"`)
+}
@@ -0,0 +1,46 @@
+package trace
+
+import (
+	"reflect"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+)
+
+// TraceProvider 提供链路追踪服务
+// 没有指定Config时，如果已经绑定了配置服务，使用配置服务中的trace配置项
+type TraceProvider struct {
+	Config
+	// Exporter 不为空时代替配置中的导出器，一般用于测试
+	Exporter contract.SpanExporter
+}
+
+// Name 将服务对应的字符串凭证返回
+func (sp *TraceProvider) Name() string {
+	return contract.TraceKey
+}
+
+// Contract 声明服务实例需要实现contract.Trace接口
+func (sp *TraceProvider) Contract() reflect.Type {
+	return framework.ContractOf[contract.Trace]()
+}
+
+// Register 注册链路追踪服务的实例化方法
+func (sp *TraceProvider) Register(c framework.Container) framework.NewInstance {
+	return NewTraceService
+}
+
+// Boot 链路追踪服务不需要准备工作
+func (sp *TraceProvider) Boot(c framework.Container) error {
+	return nil
+}
+
+// Params 返回服务容器、链路追踪配置和导出器
+func (sp *TraceProvider) Params(c framework.Container) []interface{} {
+	return []interface{}{c, sp.Config, sp.Exporter}
+}
+
+// IsDefer 导出器的配置有问题时在启动的时候就暴露出来
+func (sp *TraceProvider) IsDefer() bool {
+	return false
+}
@@ -0,0 +1,331 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"net/http"
+	"reflect"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/RZXBxie/web_server/framework/gin"
+)
+
+// Config 链路追踪服务的配置，一般放在配置服务的trace配置项中
+type Config struct {
+	// ServiceName 导出的span所属的服务名，为空时使用web
+	ServiceName string `yaml:"service_name"`
+	// Sampler 新的调用链路的采样方式：always、never、ratio，为空时使用always
+	// 上游已经决定了是否采样时，沿用上游的决定
+	Sampler string `yaml:"sampler"`
+	// SampleRatio Sampler为ratio时的采样比例
+	SampleRatio float64 `yaml:"sample_ratio"`
+	// Exporter 导出器的配置
+	Exporter ExporterConfig `yaml:"exporter"`
+	// BatchSize 每次最多导出的span的数量，为0时使用512
+	BatchSize int `yaml:"batch_size"`
+	// FlushInterval 定时导出的间隔，为0时使用5秒
+	FlushInterval time.Duration `yaml:"flush_interval"`
+	// QueueSize 等待导出的span的最大数量，超过时丢弃新的span，为0时使用2048
+	QueueSize int `yaml:"queue_size"`
+}
+
+// spanContextKey span保存在context.Context中使用的key
+type spanContextKey struct{}
+
+// TraceService 是链路追踪服务的实现
+// 结束的span先放入队列，由后台的goroutine按照批次导出，导出不会阻塞请求的处理
+// 后台的goroutine在第一个span结束时才启动，Stop时退出，没有记录过span的实例(例如MakeNew创建的)不会启动goroutine
+type TraceService struct {
+	contract.Trace
+
+	service  string
+	sample   func(traceID string) bool
+	exporter contract.SpanExporter
+
+	batchSize int
+	interval  time.Duration
+
+	queue   chan contract.SpanData
+	flushes chan chan error
+	done    chan struct{}
+	stopped chan struct{}
+
+	// runOnce 保证后台的goroutine只启动一次，running 表示已经启动
+	runOnce sync.Once
+	running atomic.Bool
+
+	closed bool
+	lock   sync.RWMutex
+}
+
+// NewTraceService 初始化链路追踪服务，参数为服务容器、链路追踪配置和导出器
+// 链路追踪配置为空并且绑定了配置服务时，使用配置服务中的trace配置项；导出器为空时按照配置创建
+func NewTraceService(params ...interface{}) (interface{}, error) {
+	c := params[0].(framework.Container)
+	cfg := params[1].(Config)
+	exporter, _ := params[2].(contract.SpanExporter)
+
+	if reflect.ValueOf(cfg).IsZero() && c.IsBind(contract.ConfigKey) {
+		config, err := framework.MakeAs[contract.Config](c, contract.ConfigKey)
+		if err != nil {
+			return nil, err
+		}
+		if config.IsExist("trace") {
+			if err := config.Load("trace", &cfg); err != nil {
+				return nil, fmt.Errorf("trace: load config: %w", err)
+			}
+		}
+	}
+	sample, err := samplerOf(cfg.Sampler, cfg.SampleRatio)
+	if err != nil {
+		return nil, err
+	}
+	if exporter == nil {
+		if exporter, err = cfg.Exporter.Open(); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.ServiceName == "" {
+		cfg.ServiceName = "web"
+	}
+	if cfg.BatchSize <= 0 {
+		cfg.BatchSize = 512
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = 5 * time.Second
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = 2048
+	}
+
+	s := &TraceService{
+		service:   cfg.ServiceName,
+		sample:    sample,
+		exporter:  exporter,
+		batchSize: cfg.BatchSize,
+		interval:  cfg.FlushInterval,
+		queue:     make(chan contract.SpanData, cfg.QueueSize),
+		flushes:   make(chan chan error),
+		done:      make(chan struct{}),
+		stopped:   make(chan struct{}),
+	}
+	return s, nil
+}
+
+// samplerOf 根据配置返回决定新的调用链路是否采样的函数
+func samplerOf(sampler string, ratio float64) (func(traceID string) bool, error) {
+	switch sampler {
+	case "", "always":
+		return func(string) bool { return true }, nil
+	case "never":
+		return func(string) bool { return false }, nil
+	case "ratio":
+		if ratio < 0 || ratio > 1 {
+			return nil, fmt.Errorf("trace: sample ratio %v is out of [0, 1]", ratio)
+		}
+		// 按照TraceID的后16位十六进制决定，同一个调用链路在不同的服务中决定相同
+		bound := uint64(ratio * math.MaxUint64)
+		return func(traceID string) bool {
+			n, _ := strconv.ParseUint(traceID[16:], 16, 64)
+			return ratio == 1 || n < bound
+		}, nil
+	}
+	return nil, fmt.Errorf("trace: unknown sampler %q", sampler)
+}
+
+func (s *TraceService) Start(ctx context.Context, name string, kind contract.SpanKind) (context.Context, contract.Span) {
+	ctx = requestContext(ctx)
+	parent := s.SpanFromContext(ctx).SpanContext()
+	sc := contract.SpanContext{SpanID: newID(8)}
+	if parent.IsValid() {
+		sc.TraceID, sc.Sampled, sc.TraceState = parent.TraceID, parent.Sampled, parent.TraceState
+	} else {
+		sc.TraceID = newID(16)
+		sc.Sampled = s.sample(sc.TraceID)
+	}
+	sp := &span{service: s, sc: sc}
+	if sc.Sampled {
+		sp.data = contract.SpanData{
+			Name:       name,
+			Kind:       kind,
+			Service:    s.service,
+			TraceID:    sc.TraceID,
+			SpanID:     sc.SpanID,
+			TraceState: sc.TraceState,
+			Start:      time.Now(),
+			Status:     contract.SpanStatusUnset,
+		}
+		if parent.IsValid() {
+			sp.data.ParentSpanID = parent.SpanID
+		}
+	}
+	return context.WithValue(ctx, spanContextKey{}, contract.Span(sp)), sp
+}
+
+func (s *TraceService) SpanFromContext(ctx context.Context) contract.Span {
+	if ctx != nil {
+		if sp, ok := requestContext(ctx).Value(spanContextKey{}).(contract.Span); ok {
+			return sp
+		}
+	}
+	return noopSpan{}
+}
+
+// Extract 请求头中的traceparent不合法时忽略，开始新的调用链路
+func (s *TraceService) Extract(ctx context.Context, header http.Header) context.Context {
+	ctx = requestContext(ctx)
+	sc, err := contract.ParseTraceparent(header.Get(contract.TraceparentHeader))
+	if err != nil {
+		return ctx
+	}
+	sc.TraceState = header.Get(contract.TracestateHeader)
+	return context.WithValue(ctx, spanContextKey{}, contract.Span(noopSpan{sc: sc}))
+}
+
+func (s *TraceService) Inject(ctx context.Context, header http.Header) {
+	sc := s.SpanFromContext(ctx).SpanContext()
+	if !sc.IsValid() {
+		return
+	}
+	header.Set(contract.TraceparentHeader, sc.Traceparent())
+	if sc.TraceState != "" {
+		header.Set(contract.TracestateHeader, sc.TraceState)
+	}
+}
+
+// requestContext *gin.Context 默认不会把Value转发给请求的context.Context，这里直接使用请求的context.Context
+func requestContext(ctx context.Context) context.Context {
+	if c, ok := ctx.(*gin.Context); ok && c.Request != nil {
+		return c.Request.Context()
+	}
+	if ctx == nil {
+		return context.Background()
+	}
+	return ctx
+}
+
+// newID 生成n个字节的随机ID，不会全为0
+func newID(n int) string {
+	const digits = "0123456789abcdef"
+	for {
+		b := make([]byte, 2*n)
+		zero := true
+		for i := 0; i < n; i += 8 {
+			v := rand.Uint64()
+			zero = zero && v == 0
+			for j := 0; j < 8 && i+j < n; j++ {
+				x := byte(v >> (8 * j))
+				b[2*(i+j)], b[2*(i+j)+1] = digits[x>>4], digits[x&0xf]
+			}
+		}
+		if !zero {
+			return string(b)
+		}
+	}
+}
+
+// enqueue 把结束的span放入队列，队列已满或者服务已经关闭时丢弃，需要时启动后台的goroutine
+func (s *TraceService) enqueue(data contract.SpanData) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.closed {
+		return
+	}
+	s.runOnce.Do(func() {
+		s.running.Store(true)
+		go s.run()
+	})
+	select {
+	case s.queue <- data:
+	default:
+	}
+}
+
+// run 在后台按照批次导出span，直到Stop
+func (s *TraceService) run() {
+	defer close(s.stopped)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	batch := make([]contract.SpanData, 0, s.batchSize)
+	export := func() error {
+		var errs []error
+		for len(batch) > 0 || len(s.queue) > 0 {
+			for len(batch) < s.batchSize && len(s.queue) > 0 {
+				batch = append(batch, <-s.queue)
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			errs = append(errs, s.exporter.ExportSpans(ctx, batch))
+			cancel()
+			batch = batch[:0]
+		}
+		return errors.Join(errs...)
+	}
+	for {
+		select {
+		case data := <-s.queue:
+			batch = append(batch, data)
+			if len(batch) >= s.batchSize {
+				_ = export()
+			}
+		case <-ticker.C:
+			_ = export()
+		case reply := <-s.flushes:
+			reply <- export()
+		case <-s.done:
+			_ = export()
+			return
+		}
+	}
+}
+
+// Flush 后台的goroutine没有启动时队列中没有span，直接返回
+func (s *TraceService) Flush(ctx context.Context) error {
+	if !s.running.Load() {
+		return nil
+	}
+	reply := make(chan error, 1)
+	select {
+	case s.flushes <- reply:
+	case <-s.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	select {
+	case err := <-reply:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Stop 服务容器关闭时导出剩下的span，并关闭导出器
+func (s *TraceService) Stop(ctx context.Context) error {
+	s.lock.Lock()
+	if s.closed {
+		s.lock.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.lock.Unlock()
+
+	// 关闭之后enqueue不会再启动后台的goroutine
+	if s.running.Load() {
+		close(s.done)
+		select {
+		case <-s.stopped:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return s.exporter.Shutdown(ctx)
+}
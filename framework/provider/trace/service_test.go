@@ -0,0 +1,190 @@
+package trace
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
+	"github.com/RZXBxie/web_server/framework/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTrace(t *testing.T, sp *TraceProvider) contract.Trace {
+	c := framework.NewContainer()
+	require.NoError(t, c.Bind(sp))
+	t.Cleanup(func() { _ = c.Shutdown(context.Background()) })
+	return framework.MustMakeAs[contract.Trace](c, contract.TraceKey)
+}
+
+func TestParseTraceparent(t *testing.T) {
+	sc, err := contract.ParseTraceparent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	require.NoError(t, err)
+	assert.Equal(t, contract.SpanContext{
+		TraceID: "4bf92f3577b34da6a3ce929d0e0e4736",
+		SpanID:  "00f067aa0ba902b7",
+		Sampled: true,
+		Remote:  true,
+	}, sc)
+	assert.Equal(t, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", sc.Traceparent())
+
+	// 更高的版本可以在后面追加字段
+	sc, err = contract.ParseTraceparent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00-extra")
+	require.NoError(t, err)
+	assert.False(t, sc.Sampled)
+
+	for _, value := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+	} {
+		_, err := contract.ParseTraceparent(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func TestTraceSpans(t *testing.T) {
+	exporter := NewMemoryExporter()
+	tracer := newTrace(t, &TraceProvider{Config: Config{ServiceName: "orders"}, Exporter: exporter})
+
+	ctx, root := tracer.Start(context.Background(), "root", contract.SpanKindServer)
+	assert.Same(t, root, tracer.SpanFromContext(ctx))
+	childCtx, child := tracer.Start(ctx, "child", contract.SpanKindClient)
+	child.SetAttributes(map[string]interface{}{"db.system": "mysql"})
+	child.AddEvent("retry", map[string]interface{}{"attempt": 2})
+	child.RecordError(errors.New("connection reset"))
+	child.End()
+	child.SetName("ignored after end")
+	root.SetName("renamed")
+	root.End()
+	root.End()
+
+	header := http.Header{}
+	tracer.Inject(childCtx, header)
+	assert.Equal(t, child.SpanContext().Traceparent(), header.Get(contract.TraceparentHeader))
+
+	require.NoError(t, tracer.Flush(context.Background()))
+	spans := exporter.Spans()
+	require.Len(t, spans, 2)
+	assert.Equal(t, "child", spans[0].Name)
+	assert.Equal(t, contract.SpanKindClient, spans[0].Kind)
+	assert.Equal(t, "orders", spans[0].Service)
+	assert.Equal(t, root.SpanContext().TraceID, spans[0].TraceID)
+	assert.Equal(t, root.SpanContext().SpanID, spans[0].ParentSpanID)
+	assert.Equal(t, "mysql", spans[0].Attributes["db.system"])
+	require.Len(t, spans[0].Events, 2)
+	assert.Equal(t, "retry", spans[0].Events[0].Name)
+	assert.Equal(t, "exception", spans[0].Events[1].Name)
+	assert.Equal(t, contract.SpanStatusError, spans[0].Status)
+	assert.Equal(t, "connection reset", spans[0].StatusMessage)
+
+	assert.Equal(t, "renamed", spans[1].Name)
+	assert.Empty(t, spans[1].ParentSpanID)
+	assert.Equal(t, contract.SpanStatusUnset, spans[1].Status)
+	assert.False(t, spans[1].End.Before(spans[1].Start))
+}
+
+func TestTraceExtract(t *testing.T) {
+	exporter := NewMemoryExporter()
+	tracer := newTrace(t, &TraceProvider{Config: Config{Sampler: "never"}, Exporter: exporter})
+
+	header := http.Header{}
+	header.Set(contract.TraceparentHeader, "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	header.Set(contract.TracestateHeader, "vendor=value")
+	ctx := tracer.Extract(context.Background(), header)
+	_, span := tracer.Start(ctx, "server", contract.SpanKindServer)
+	// 上游已经决定采样时不使用本地的采样方式
+	assert.True(t, span.IsRecording())
+	span.End()
+
+	// 没有上游时使用本地的采样方式，没有采样的span仍然传递标识
+	_, unsampled := tracer.Start(tracer.Extract(context.Background(), http.Header{}), "local", contract.SpanKindServer)
+	assert.False(t, unsampled.IsRecording())
+	assert.True(t, unsampled.SpanContext().IsValid())
+	unsampled.End()
+
+	require.NoError(t, tracer.Flush(context.Background()))
+	spans := exporter.Spans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].TraceID)
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].ParentSpanID)
+	assert.Equal(t, "vendor=value", spans[0].TraceState)
+
+	out := http.Header{}
+	tracer.Inject(ctx, out)
+	assert.Equal(t, header.Get(contract.TraceparentHeader), out.Get(contract.TraceparentHeader))
+	assert.Equal(t, "vendor=value", out.Get(contract.TracestateHeader))
+}
+
+func TestTraceGinContext(t *testing.T) {
+	exporter := NewMemoryExporter()
+	tracer := newTrace(t, &TraceProvider{Exporter: exporter})
+
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest(http.MethodGet, "/", nil)
+	ctx, parent := tracer.Start(c.Request.Context(), "request", contract.SpanKindServer)
+	c.Request = c.Request.WithContext(ctx)
+
+	_, child := tracer.Start(c, "handler", contract.SpanKindInternal)
+	assert.Equal(t, parent.SpanContext().TraceID, child.SpanContext().TraceID)
+	assert.Same(t, parent, tracer.SpanFromContext(c))
+}
+
+func TestTraceRatioSampler(t *testing.T) {
+	sample, err := samplerOf("ratio", 0.5)
+	require.NoError(t, err)
+	assert.True(t, sample(strings.Repeat("0", 16)+"7fffffffffffffff"))
+	assert.False(t, sample(strings.Repeat("0", 16)+"8000000000000001"))
+
+	_, err = samplerOf("ratio", 2)
+	assert.Error(t, err)
+	_, err = samplerOf("sometimes", 0)
+	assert.EqualError(t, err, `trace: unknown sampler "sometimes"`)
+}
+
+func TestTraceStopExports(t *testing.T) {
+	exporter := NewMemoryExporter()
+	c := framework.NewContainer()
+	require.NoError(t, c.Bind(&TraceProvider{Exporter: exporter}))
+	tracer := framework.MustMakeAs[contract.Trace](c, contract.TraceKey)
+
+	_, span := tracer.Start(context.Background(), "pending", contract.SpanKindInternal)
+	span.End()
+	require.NoError(t, c.Shutdown(context.Background()))
+	assert.Len(t, exporter.Spans(), 1)
+
+	// 关闭之后结束的span被丢弃
+	_, span = tracer.Start(context.Background(), "late", contract.SpanKindInternal)
+	span.End()
+	assert.NoError(t, tracer.Flush(context.Background()))
+	assert.Len(t, exporter.Spans(), 1)
+}
+
+func TestTraceStartsExportLazily(t *testing.T) {
+	exporter := NewMemoryExporter()
+	c := framework.NewContainer()
+	require.NoError(t, c.Bind(&TraceProvider{Exporter: exporter}))
+
+	// 没有记录过span的实例不启动后台的goroutine
+	ins, err := c.MakeNew(contract.TraceKey, c, Config{}, exporter)
+	require.NoError(t, err)
+	tracer := ins.(*TraceService)
+	assert.False(t, tracer.running.Load())
+	assert.NoError(t, tracer.Flush(context.Background()))
+
+	_, span := tracer.Start(context.Background(), "first", contract.SpanKindInternal)
+	span.End()
+	assert.True(t, tracer.running.Load())
+	require.NoError(t, tracer.Stop(context.Background()))
+	assert.Len(t, exporter.Spans(), 1)
+}
@@ -0,0 +1,108 @@
+package trace
+
+import (
+	"maps"
+	"sync"
+	"time"
+
+	"github.com/RZXBxie/web_server/framework/contract"
+)
+
+// span 是 TraceService 创建的span，没有被采样时只用于传递标识，不记录任何信息
+type span struct {
+	service *TraceService
+	sc      contract.SpanContext
+
+	data  contract.SpanData
+	ended bool
+	lock  sync.Mutex
+}
+
+func (sp *span) SpanContext() contract.SpanContext {
+	return sp.sc
+}
+
+func (sp *span) IsRecording() bool {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+	return sp.recording()
+}
+
+// recording 调用方需要持有锁
+func (sp *span) recording() bool {
+	return sp.sc.Sampled && !sp.ended
+}
+
+func (sp *span) SetName(name string) {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+	if sp.recording() {
+		sp.data.Name = name
+	}
+}
+
+func (sp *span) SetAttributes(attrs map[string]interface{}) {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+	if !sp.recording() {
+		return
+	}
+	if sp.data.Attributes == nil {
+		sp.data.Attributes = make(map[string]interface{}, len(attrs))
+	}
+	maps.Copy(sp.data.Attributes, attrs)
+}
+
+func (sp *span) AddEvent(name string, attrs map[string]interface{}) {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+	if sp.recording() {
+		sp.data.Events = append(sp.data.Events, contract.SpanEvent{Name: name, Time: time.Now(), Attributes: maps.Clone(attrs)})
+	}
+}
+
+// RecordError 事件的名字和属性遵循OpenTelemetry的约定
+func (sp *span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	sp.AddEvent("exception", map[string]interface{}{"exception.message": err.Error()})
+	sp.SetStatus(contract.SpanStatusError, err.Error())
+}
+
+func (sp *span) SetStatus(status contract.SpanStatus, msg string) {
+	sp.lock.Lock()
+	defer sp.lock.Unlock()
+	if sp.recording() {
+		sp.data.Status, sp.data.StatusMessage = status, msg
+	}
+}
+
+// End 只有第一次调用有效
+func (sp *span) End() {
+	sp.lock.Lock()
+	recording := sp.recording()
+	if recording {
+		sp.data.End = time.Now()
+	}
+	sp.ended = true
+	data := sp.data
+	sp.lock.Unlock()
+	if recording {
+		sp.service.enqueue(data)
+	}
+}
+
+// noopSpan 不记录任何信息的span，用于context.Context中没有span的情况，以及从请求头中解析出来的上游span
+type noopSpan struct {
+	sc contract.SpanContext
+}
+
+func (sp noopSpan) SpanContext() contract.SpanContext       { return sp.sc }
+func (sp noopSpan) IsRecording() bool                       { return false }
+func (sp noopSpan) SetName(string)                          {}
+func (sp noopSpan) SetAttributes(map[string]interface{})    {}
+func (sp noopSpan) AddEvent(string, map[string]interface{}) {}
+func (sp noopSpan) RecordError(error)                       {}
+func (sp noopSpan) SetStatus(contract.SpanStatus, string)   {}
+func (sp noopSpan) End()                                    {}
@@ -0,0 +1,184 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// ErrScopeShutdown 作用域已经关闭，不能再实例化Scoped服务
+var ErrScopeShutdown = errors.New("scope is shut down")
+
+// Scope 是服务容器的一个作用域，一般一个请求对应一个作用域
+// Scoped生命周期的服务在作用域中只实例化一次，作用域关闭时按照实例化相反的顺序停止
+// 单例仍然由根容器管理，Transient服务每次都创建新的实例
+type Scope struct {
+	// root 是创建这个作用域的根容器
+	root *MyContainer
+
+	// instances 存储作用域中的Scoped服务实例
+	instances map[string]interface{}
+
+	// order 按照实例化完成的顺序存储Scoped服务
+	order []orderedInstance
+
+	// shutdown 表示作用域已经关闭
+	shutdown bool
+
+	lock sync.Mutex
+}
+
+var _ Container = (*Scope)(nil)
+
+func (c *MyContainer) NewScope() Container {
+	return &Scope{root: c, instances: make(map[string]interface{})}
+}
+
+func (s *Scope) Bind(provider ServiceProvider) error {
+	return s.root.Bind(provider)
+}
+
+func (s *Scope) IsBind(key string) bool {
+	return s.root.IsBind(key)
+}
+
+func (s *Scope) Make(key string) (interface{}, error) {
+	return s.make(key, nil, false)
+}
+
+func (s *Scope) MustMake(key string) interface{} {
+	service, err := s.make(key, nil, false)
+	if err != nil {
+		panic(err)
+	}
+	return service
+}
+
+func (s *Scope) MakeNew(key string, params ...interface{}) (interface{}, error) {
+	return s.make(key, params, true)
+}
+
+// Decorate 装饰器注册在根容器上，对所有作用域生效
+func (s *Scope) Decorate(key string, decorator Decorator) error {
+	return s.root.Decorate(key, decorator)
+}
+
+// Warmup 预热的是根容器中的单例
+func (s *Scope) Warmup(ctx context.Context, timeout time.Duration, keys ...string) ([]WarmupResult, error) {
+	return s.root.Warmup(ctx, timeout, keys...)
+}
+
+func (s *Scope) TaggedKeys(tag string) []string {
+	return s.root.TaggedKeys(tag)
+}
+
+// MakeTagged 在作用域中获取带有标签tag的所有服务，Scoped服务在作用域中只实例化一次
+func (s *Scope) MakeTagged(tag string) ([]interface{}, error) {
+	return makeTagged(s, s.root.TaggedKeys(tag))
+}
+
+func (s *Scope) KeyOf(contract reflect.Type) (string, error) {
+	return s.root.KeyOf(contract)
+}
+
+func (s *Scope) Bindings() []BindingInfo {
+	return s.root.Bindings()
+}
+
+func (s *Scope) NewChild() Container {
+	return s.root.NewChild()
+}
+
+// NewScope 作用域不能嵌套，创建的是根容器上一个新的作用域
+func (s *Scope) NewScope() Container {
+	return s.root.NewScope()
+}
+
+// make 在作用域中实例化一个服务
+func (s *Scope) make(key string, params []interface{}, forceNew bool) (interface{}, error) {
+	if !forceNew {
+		if ins, ok := s.cached(key); ok {
+			return ins, nil
+		}
+	}
+	s.root.lock.RLock()
+	sp, err := s.root.findProvider(key)
+	s.root.lock.RUnlock()
+	if err != nil {
+		return nil, err
+	}
+	// 使用默认具名实现时，和直接使用具名实现的关键字凭证共享同一个实例
+	key = providerKey(sp)
+
+	lifetime := providerLifetime(sp)
+	if lifetime == Singleton && !forceNew {
+		return s.root.make(key, nil, false)
+	}
+	// 依赖在作用域中实例化，这样Scoped服务可以依赖同一个作用域中的其他Scoped服务
+	if err := makeDepends(s, sp); err != nil {
+		return nil, err
+	}
+	if forceNew || lifetime == Transient {
+		return s.root.newInstance(s, sp, params)
+	}
+
+	s.lock.Lock()
+	if ins, ok := s.instances[key]; ok {
+		s.lock.Unlock()
+		return ins, nil
+	}
+	s.lock.Unlock()
+
+	ins, err := s.root.newInstance(s, sp, nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := startInstance(s.root.ctx, key, ins); err != nil {
+		return nil, err
+	}
+
+	s.lock.Lock()
+	if s.shutdown {
+		s.lock.Unlock()
+		_ = stopInstance(context.Background(), key, ins)
+		return nil, ErrScopeShutdown
+	}
+	// 同一个作用域中并发实例化了同一个服务，保留先完成的实例
+	if exist, ok := s.instances[key]; ok {
+		s.lock.Unlock()
+		_ = stopInstance(context.Background(), key, ins)
+		return exist, nil
+	}
+	s.instances[key] = ins
+	s.order = append(s.order, orderedInstance{key: key, instance: ins})
+	s.lock.Unlock()
+	return ins, nil
+}
+
+// cached 返回作用域中已经实例化的Scoped服务或者根容器中已经实例化的单例
+func (s *Scope) cached(key string) (interface{}, bool) {
+	s.lock.Lock()
+	ins, ok := s.instances[key]
+	s.lock.Unlock()
+	if ok {
+		return ins, true
+	}
+	return s.root.cached(key)
+}
+
+// Shutdown 停止作用域中的Scoped服务，不影响根容器中的单例
+func (s *Scope) Shutdown(ctx context.Context) error {
+	s.lock.Lock()
+	if s.shutdown {
+		s.lock.Unlock()
+		return nil
+	}
+	s.shutdown = true
+	order := s.order
+	s.order, s.instances = nil, nil
+	s.lock.Unlock()
+
+	return stopInstances(ctx, order)
+}
@@ -0,0 +1,114 @@
+package framework
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lifetimeProvider 每次实例化都返回一个新的lifecycleService
+type lifetimeProvider struct {
+	*testProvider
+	lifetime Lifetime
+	events   *[]string
+}
+
+func (p *lifetimeProvider) Lifetime() Lifetime { return p.lifetime }
+
+func (p *lifetimeProvider) Register(Container) NewInstance {
+	return func(...interface{}) (interface{}, error) {
+		return &lifecycleService{name: p.name, events: p.events, mu: p.mu}, nil
+	}
+}
+
+func bindLifetime(t *testing.T, c *MyContainer, events *[]string, name string, lifetime Lifetime, depends ...string) {
+	p := &lifetimeProvider{testProvider: newTestProvider(name, true, depends...), lifetime: lifetime, events: events}
+	p.mu = &sync.Mutex{}
+	require.NoError(t, c.Bind(p))
+}
+
+func TestScopeLifetimes(t *testing.T) {
+	c := NewContainer()
+	events := &[]string{}
+	bindLifetime(t, c, events, "singleton", Singleton)
+	bindLifetime(t, c, events, "scoped", Scoped, "singleton")
+	bindLifetime(t, c, events, "transient", Transient)
+
+	first, second := c.NewScope(), c.NewScope()
+	a, err := first.Make("scoped")
+	require.NoError(t, err)
+	b, err := first.Make("scoped")
+	require.NoError(t, err)
+	other, err := second.Make("scoped")
+	require.NoError(t, err)
+	assert.Same(t, a, b)
+	assert.NotSame(t, a, other)
+
+	s1, err := first.Make("singleton")
+	require.NoError(t, err)
+	s2, err := c.Make("singleton")
+	require.NoError(t, err)
+	assert.Same(t, s1, s2)
+
+	t1, err := first.Make("transient")
+	require.NoError(t, err)
+	t2, err := c.Make("transient")
+	require.NoError(t, err)
+	assert.NotSame(t, t1, t2)
+
+	require.NoError(t, first.Shutdown(context.Background()))
+	assert.Equal(t, []string{"start singleton", "start scoped", "start scoped", "stop scoped"}, *events)
+	_, err = first.Make("scoped")
+	assert.ErrorIs(t, err, ErrScopeShutdown)
+
+	// 作用域关闭不影响单例
+	_, err = second.Make("singleton")
+	require.NoError(t, err)
+}
+
+func TestScopedServiceOutsideScope(t *testing.T) {
+	c := NewContainer()
+	events := &[]string{}
+	bindLifetime(t, c, events, "scoped", Scoped)
+	bindLifetime(t, c, events, "captive", Singleton, "scoped")
+
+	_, err := c.Make("scoped")
+	assert.EqualError(t, err, "contract scoped is scoped and can only be made in a scope")
+	_, err = c.NewScope().Make("captive")
+	assert.EqualError(t, err, "contract scoped is scoped and can only be made in a scope")
+}
+
+func TestMakeNewReturnsNewInstance(t *testing.T) {
+	c := NewContainer()
+	bindLifetime(t, c, &[]string{}, "singleton", Singleton)
+
+	a, err := c.Make("singleton")
+	require.NoError(t, err)
+	b, err := c.MakeNew("singleton")
+	require.NoError(t, err)
+	assert.NotSame(t, a, b)
+}
+
+func TestCachedInstance(t *testing.T) {
+	c := NewContainer()
+	require.NoError(t, c.Bind(newTestProvider("config", true)))
+	child := c.NewChild()
+	scope := child.NewScope()
+
+	// 还没有实例化的服务不会被实例化
+	_, ok := CachedInstance(scope, "config")
+	assert.False(t, ok)
+	_, err := c.Make("config")
+	require.NoError(t, err)
+
+	for _, in := range []Container{c, child, scope} {
+		ins, ok := CachedInstance(in, "config")
+		assert.True(t, ok)
+		assert.Equal(t, "config", ins)
+	}
+	_, ok = CachedInstance(scope, "missing")
+	assert.False(t, ok)
+}
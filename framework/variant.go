@@ -0,0 +1,139 @@
+package framework
+
+import (
+	"slices"
+)
+
+// ServiceVariant 是服务提供者可以选择实现的接口，声明自己是关键字凭证Name()下的一个具名实现
+// 同一个关键字凭证下的多个具名实现可以同时绑定而不会互相覆盖，具名实现使用 VariantKey(Name(), Variant()) 获取
+// 使用Name()获取时返回默认实现，默认实现的选择规则见 ServiceDefault
+type ServiceVariant interface {
+	// Variant 返回具名实现的名字，为空时和普通的服务提供者相同
+	Variant() string
+}
+
+// ServiceDefault 是具名实现可以选择实现的接口，用于声明自己是关键字凭证下的默认实现
+// 使用Name()获取服务时按照下面的规则选择：
+//  1. 直接以Name()绑定的非具名服务提供者
+//  2. 声明了IsDefault()的具名实现，有多个时选择最后绑定的
+//  3. 最先绑定的具名实现
+type ServiceDefault interface {
+	IsDefault() bool
+}
+
+// ServiceTagger 是服务提供者可以选择实现的接口，用于给服务打上标签，使用 MakeTagged 获取带有某个标签的所有服务
+type ServiceTagger interface {
+	Tags() []string
+}
+
+// VariantKey 返回关键字凭证key下名为variant的具名实现的关键字凭证
+func VariantKey(key, variant string) string {
+	return key + "@" + variant
+}
+
+// providerVariant 获取服务提供者声明的具名实现的名字
+func providerVariant(sp ServiceProvider) string {
+	if v, ok := sp.(ServiceVariant); ok {
+		return v.Variant()
+	}
+	return ""
+}
+
+// providerKey 获取服务提供者绑定在容器中的关键字凭证，具名实现的关键字凭证包含了实现的名字
+func providerKey(sp ServiceProvider) string {
+	if variant := providerVariant(sp); variant != "" {
+		return VariantKey(sp.Name(), variant)
+	}
+	return sp.Name()
+}
+
+// providerIsDefault 具名实现是否声明了自己是默认实现
+func providerIsDefault(sp ServiceProvider) bool {
+	if d, ok := sp.(ServiceDefault); ok {
+		return d.IsDefault()
+	}
+	return false
+}
+
+// providerTags 获取服务提供者的标签
+func providerTags(sp ServiceProvider) []string {
+	if t, ok := sp.(ServiceTagger); ok {
+		return t.Tags()
+	}
+	return nil
+}
+
+// ownKey 把key解析为这个容器中绑定的关键字凭证，不查找父容器，调用方需要持有锁
+// key没有直接绑定时按照 ServiceDefault 的规则选择默认的具名实现，都不存在时原样返回key和false
+func (c *MyContainer) ownKey(key string) (string, bool) {
+	if _, ok := c.providers[key]; ok {
+		return key, true
+	}
+	variants := c.variants[key]
+	if len(variants) == 0 {
+		return key, false
+	}
+	for i := len(variants) - 1; i >= 0; i-- {
+		if providerIsDefault(c.providers[variants[i]]) {
+			return variants[i], true
+		}
+	}
+	return variants[0], true
+}
+
+// TaggedKeys 按照绑定的顺序返回带有标签tag的关键字凭证，父容器的在前，被子容器覆盖的绑定只保留子容器的
+func (c *MyContainer) TaggedKeys(tag string) []string {
+	var keys []string
+	if c.parent != nil {
+		keys = c.parent.TaggedKeys(tag)
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	keys = slices.DeleteFunc(keys, func(key string) bool {
+		_, ok := c.providers[key]
+		return ok
+	})
+	for _, key := range c.keys {
+		if slices.Contains(providerTags(c.providers[key]), tag) {
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (c *MyContainer) MakeTagged(tag string) ([]interface{}, error) {
+	return makeTagged(c, c.TaggedKeys(tag))
+}
+
+// makeTagged 在容器c中获取keys对应的所有服务
+func makeTagged(c Container, keys []string) ([]interface{}, error) {
+	instances := make([]interface{}, 0, len(keys))
+	for _, key := range keys {
+		instance, err := c.Make(key)
+		if err != nil {
+			return nil, err
+		}
+		instances = append(instances, instance)
+	}
+	return instances, nil
+}
+
+// TaggedMaker 能够获取带有某个标签的所有服务，Container 和 gin.Context 都实现了这个接口
+type TaggedMaker interface {
+	Maker
+	TaggedKeys(tag string) []string
+}
+
+// MakeTaggedAs 按照绑定的顺序获取带有标签tag的所有服务，并转换为类型T，任何一个实例不是类型T都返回 *ContractError
+func MakeTaggedAs[T any](m TaggedMaker, tag string) ([]T, error) {
+	keys := m.TaggedKeys(tag)
+	services := make([]T, 0, len(keys))
+	for _, key := range keys {
+		service, err := MakeAs[T](m, key)
+		if err != nil {
+			return nil, err
+		}
+		services = append(services, service)
+	}
+	return services, nil
+}
@@ -0,0 +1,139 @@
+package framework
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// variantProvider 是关键字凭证notify下的一个具名实现，实例是实现的名字
+type variantProvider struct {
+	*instanceProvider
+	variant   string
+	isDefault bool
+	tags      []string
+}
+
+func (p *variantProvider) Variant() string        { return p.variant }
+func (p *variantProvider) IsDefault() bool        { return p.isDefault }
+func (p *variantProvider) Tags() []string         { return p.tags }
+func (p *variantProvider) Contract() reflect.Type { return ContractOf[fmt.Stringer]() }
+
+func newVariant(variant string, isDefault bool, tags ...string) *variantProvider {
+	return &variantProvider{
+		instanceProvider: &instanceProvider{testProvider: newTestProvider("notify", true), instance: notifier(variant)},
+		variant:          variant,
+		isDefault:        isDefault,
+		tags:             tags,
+	}
+}
+
+type notifier string
+
+func (n notifier) String() string { return string(n) }
+
+func TestVariantsDoNotOverwrite(t *testing.T) {
+	c := NewContainer()
+	require.NoError(t, c.Bind(newVariant("email", false, "channel")))
+	require.NoError(t, c.Bind(newVariant("sms", false, "channel", "urgent")))
+
+	email, err := c.Make(VariantKey("notify", "email"))
+	require.NoError(t, err)
+	assert.Equal(t, notifier("email"), email)
+	sms, err := c.Make("notify@sms")
+	require.NoError(t, err)
+	assert.Equal(t, notifier("sms"), sms)
+
+	// 没有声明默认实现时使用最先绑定的具名实现
+	def, err := c.Make("notify")
+	require.NoError(t, err)
+	assert.Equal(t, notifier("email"), def)
+	assert.True(t, c.IsBind("notify"))
+
+	// 声明了默认实现之后使用默认实现
+	require.NoError(t, c.Bind(newVariant("slack", true, "channel")))
+	def, err = c.Make("notify")
+	require.NoError(t, err)
+	assert.Equal(t, notifier("slack"), def)
+
+	// 直接以关键字凭证绑定的服务优先于具名实现
+	bindInstance(t, c, "notify", notifier("plain"))
+	def, err = c.Make("notify")
+	require.NoError(t, err)
+	assert.Equal(t, notifier("plain"), def)
+	email, err = c.Make("notify@email")
+	require.NoError(t, err)
+	assert.Equal(t, notifier("email"), email)
+}
+
+func TestVariantsShareInstanceWithDefault(t *testing.T) {
+	c := NewContainer()
+	counting := &countingProvider{name: "counting"}
+	require.NoError(t, c.Bind(&struct {
+		*countingProvider
+		ServiceVariant
+	}{counting, variantName("only")}))
+
+	a, err := c.Make("counting")
+	require.NoError(t, err)
+	b, err := c.Make("counting@only")
+	require.NoError(t, err)
+	assert.Same(t, a, b)
+	assert.EqualValues(t, 1, counting.created.Load())
+
+	infos := c.Bindings()
+	require.Len(t, infos, 1)
+	assert.Equal(t, "counting@only", infos[0].Key)
+	assert.Equal(t, "counting", infos[0].Name)
+	assert.Equal(t, "only", infos[0].Variant)
+	assert.True(t, infos[0].Default)
+}
+
+type variantName string
+
+func (v variantName) Variant() string { return string(v) }
+
+func TestMakeTagged(t *testing.T) {
+	c := NewContainer()
+	require.NoError(t, c.Bind(newVariant("email", false, "channel")))
+	require.NoError(t, c.Bind(newVariant("sms", false, "channel", "urgent")))
+	bindInstance(t, c, "other", "not tagged")
+
+	assert.Equal(t, []string{"notify@email", "notify@sms"}, c.TaggedKeys("channel"))
+	channels, err := c.MakeTagged("channel")
+	require.NoError(t, err)
+	assert.Equal(t, []interface{}{notifier("email"), notifier("sms")}, channels)
+
+	urgent, err := MakeTaggedAs[fmt.Stringer](c.NewScope(), "urgent")
+	require.NoError(t, err)
+	require.Len(t, urgent, 1)
+	assert.Equal(t, "sms", urgent[0].String())
+
+	_, err = MakeTaggedAs[error](c, "channel")
+	assert.EqualError(t, err, "contract notify@email: instance of type framework.notifier does not implement error")
+
+	// 子容器覆盖的具名实现替换父容器中的同一个绑定
+	child := c.NewChild()
+	require.NoError(t, child.Bind(newVariant("email", false, "channel")))
+	require.NoError(t, child.Bind(newVariant("push", false, "channel")))
+	assert.Equal(t, []string{"notify@sms", "notify@email", "notify@push"}, child.TaggedKeys("channel"))
+
+	empty, err := c.MakeTagged("missing")
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+}
+
+func TestKeyOfVariants(t *testing.T) {
+	c := NewContainer()
+	require.NoError(t, c.Bind(newVariant("email", false)))
+	require.NoError(t, c.Bind(newVariant("sms", true)))
+
+	var target struct {
+		Notifier fmt.Stringer `inject:""`
+	}
+	require.NoError(t, Inject(c, &target))
+	assert.Equal(t, "sms", target.Notifier.String())
+}
@@ -0,0 +1,82 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// WarmupResult 是一个服务预热的结果
+type WarmupResult struct {
+	// Key 服务的关键字凭证
+	Key string `json:"key"`
+	// Duration 实例化的耗时，超时的服务为等待的时间
+	Duration time.Duration `json:"duration_ns"`
+	// Err 实例化失败或者超时的原因
+	Err error `json:"-"`
+}
+
+// Warmup 在启动服务器之前并发地实例化keys对应的单例，这样第一个请求不需要承担服务Boot的耗时
+// keys为空时预热所有还没有实例化的延迟单例，有依赖关系的服务仍然按照依赖顺序实例化，同一个服务只会实例化一次
+// timeout 是每个服务的超时时间，为0时只受ctx的限制；超时的服务不会被取消，实例化完成之后仍然会被缓存
+// 返回按照keys顺序排列的预热结果，所有失败的服务的error合并之后一起返回
+func (c *MyContainer) Warmup(ctx context.Context, timeout time.Duration, keys ...string) ([]WarmupResult, error) {
+	if len(keys) == 0 {
+		for _, info := range c.Bindings() {
+			if info.Defer && info.Lifetime == Singleton.String() && !info.Instantiated {
+				keys = append(keys, info.Key)
+			}
+		}
+	}
+
+	results := make([]WarmupResult, len(keys))
+	var wg sync.WaitGroup
+	for i, key := range keys {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.warmup(ctx, timeout, key)
+		}()
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, result.Err)
+		}
+	}
+	return results, errors.Join(errs...)
+}
+
+// warmup 实例化一个服务，等待实例化完成或者超时
+func (c *MyContainer) warmup(ctx context.Context, timeout time.Duration, key string) WarmupResult {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("contract %s panic during make: %v", key, p)
+			}
+		}()
+		_, err := c.make(key, nil, false)
+		done <- err
+	}()
+
+	result := WarmupResult{Key: key}
+	select {
+	case err := <-done:
+		result.Err = err
+	case <-ctx.Done():
+		result.Err = fmt.Errorf("contract %s warm up: %w", key, ctx.Err())
+	}
+	result.Duration = time.Since(start)
+	return result
+}
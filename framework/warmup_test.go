@@ -0,0 +1,103 @@
+package framework
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// slowProvider 的Boot需要等待一段时间，用于模拟耗时的启动
+type slowProvider struct {
+	*testProvider
+	delay time.Duration
+}
+
+func (p *slowProvider) Boot(c Container) error {
+	time.Sleep(p.delay)
+	return p.testProvider.Boot(c)
+}
+
+func bindSlow(t *testing.T, c *MyContainer, name string, delay time.Duration, depends ...string) *slowProvider {
+	p := &slowProvider{testProvider: newTestProvider(name, true, depends...), delay: delay}
+	require.NoError(t, c.Bind(p))
+	return p
+}
+
+func TestWarmupBootsConcurrently(t *testing.T) {
+	c := NewContainer()
+	a := bindSlow(t, c, "a", 100*time.Millisecond)
+	b := bindSlow(t, c, "b", 100*time.Millisecond)
+	dep := bindSlow(t, c, "dep", 100*time.Millisecond, "a")
+	booted := share(a.testProvider, b.testProvider, dep.testProvider)
+	bindLifetime(t, c, &[]string{}, "transient", Transient)
+
+	start := time.Now()
+	results, err := c.Warmup(context.Background(), time.Second)
+	require.NoError(t, err)
+	// a和b并发启动，dep等待a启动完成
+	assert.Less(t, time.Since(start), 290*time.Millisecond)
+
+	keys := make([]string, 0, len(results))
+	for _, result := range results {
+		keys = append(keys, result.Key)
+		assert.GreaterOrEqual(t, result.Duration, 100*time.Millisecond)
+	}
+	assert.Equal(t, []string{"a", "b", "dep"}, keys)
+	assert.ElementsMatch(t, []string{"a", "b", "dep"}, *booted)
+	assert.Less(t, slices.Index(*booted, "a"), slices.Index(*booted, "dep"))
+
+	// 已经实例化的服务不会再次预热
+	results, err = c.Warmup(context.Background(), time.Second)
+	require.NoError(t, err)
+	assert.Empty(t, results)
+}
+
+func TestWarmupAggregatesErrors(t *testing.T) {
+	c := NewContainer()
+	bindSlow(t, c, "slow", 200*time.Millisecond)
+	failed := newTestProvider("failed", true)
+	failed.bootErr = errors.New("boot failed")
+	require.NoError(t, c.Bind(failed))
+	bindSlow(t, c, "fast", 0)
+
+	results, err := c.Warmup(context.Background(), 50*time.Millisecond, "slow", "failed", "fast", "missing")
+	require.Error(t, err)
+	require.Len(t, results, 4)
+	assert.ErrorIs(t, results[0].Err, context.DeadlineExceeded)
+	assert.Less(t, results[0].Duration, 200*time.Millisecond)
+	assert.EqualError(t, results[1].Err, "boot failed")
+	assert.NoError(t, results[2].Err)
+	assert.EqualError(t, results[3].Err, "contract missing not found.")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.ErrorContains(t, err, "boot failed")
+
+	// 超时的服务仍然会完成实例化并被缓存
+	assert.Eventually(t, func() bool {
+		return slices.ContainsFunc(c.Bindings(), func(info BindingInfo) bool {
+			return info.Key == "slow" && info.Instantiated
+		})
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestWarmupConcurrentWithMake(t *testing.T) {
+	c := NewContainer()
+	p := &countingProvider{name: "counting"}
+	require.NoError(t, c.Bind(p))
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, _ = c.Make("counting")
+	}()
+	_, err := c.Warmup(context.Background(), time.Second, "counting", "counting")
+	require.NoError(t, err)
+	wg.Wait()
+	assert.EqualValues(t, 1, p.created.Load())
+}
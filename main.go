@@ -2,29 +2,100 @@ package main
 
 import (
 	"context"
+	"io"
 	"log"
 	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/apperr"
+	"github.com/RZXBxie/web_server/framework/contract"
 	"github.com/RZXBxie/web_server/framework/gin"
 	"github.com/RZXBxie/web_server/framework/middleware"
+	"github.com/RZXBxie/web_server/framework/provider/app"
+	"github.com/RZXBxie/web_server/framework/provider/config"
+	"github.com/RZXBxie/web_server/framework/provider/health"
+	logprovider "github.com/RZXBxie/web_server/framework/provider/log"
+	"github.com/RZXBxie/web_server/framework/provider/metrics"
+	"github.com/RZXBxie/web_server/framework/provider/ratelimit"
+	"github.com/RZXBxie/web_server/framework/provider/trace"
 	"github.com/RZXBxie/web_server/provider/demo"
 )
 
 func main() {
+	// 应用环境服务决定了gin的模式和配置目录，需要先于Engine和配置服务创建，Engine的调试输出才会使用配置的模式
+	container := framework.NewContainer()
+	if err := container.Bind(&app.AppProvider{EnvName: "WEB_ENV", Args: os.Args[1:]}); err != nil {
+		log.Fatalf("bind app provider error: %v", err)
+	}
 	core := gin.New()
+	core.SetContainer(container)
 
-	// 绑定服务提供者
+	// 绑定其他服务提供者
+	if err := core.Bind(&config.ConfigProvider{Source: config.Source{
+		EnvPrefix:      "WEB_",
+		Args:           os.Args[1:],
+		ReloadInterval: 3 * time.Second,
+	}}); err != nil {
+		log.Fatalf("bind config provider error: %v", err)
+	}
+	if err := core.Bind(&logprovider.LogProvider{}); err != nil {
+		log.Fatalf("bind log provider error: %v", err)
+	}
+	if err := core.Bind(&health.HealthProvider{}); err != nil {
+		log.Fatalf("bind health provider error: %v", err)
+	}
+	if err := core.Bind(&metrics.MetricsProvider{}); err != nil {
+		log.Fatalf("bind metrics provider error: %v", err)
+	}
+	if err := core.Bind(&trace.TraceProvider{}); err != nil {
+		log.Fatalf("bind trace provider error: %v", err)
+	}
+	if err := core.Bind(&ratelimit.RateLimitProvider{}); err != nil {
+		log.Fatalf("bind ratelimit provider error: %v", err)
+	}
 	core.Bind(&demo.DemoServiceProvider{})
+	configService := framework.MustMakeAs[contract.Config](core.Container(), contract.ConfigKey)
 
-	core.Use(middleware.Recovery())
-	core.Use(middleware.Cost())
+	// 请求ID需要在其他中间件之前设置，后面的日志中才能带上请求ID
+	core.Use(middleware.RequestID())
+	// 每个请求一个server span，上游通过traceparent请求头传递调用链路
+	core.Use(middleware.Trace())
+	// 访问日志和应用日志使用不同的文件，分别切割
+	accessLog, err := openAccessLog(core, configService)
+	if err != nil {
+		log.Fatalf("open access log error: %v", err)
+	}
+	if accessLog != nil {
+		defer accessLog.Close()
+		core.Use(gin.LoggerWithConfig(gin.LoggerConfig{Output: accessLog}))
+	}
+	// 按照路由模板统计请求的数量、耗时和大小
+	core.Use(middleware.Metrics())
+	// 统一处理panic和handler通过c.Error记录的错误，放在日志中间件之后，访问日志中记录的是最终的状态码
+	// 下游调用超时按照504返回
+	core.Use(middleware.ErrorHandler(middleware.MapError(context.DeadlineExceeded, apperr.ErrTimeout)))
+	// 只信任配置中的代理转发的X-Forwarded-For，按照IP限流时客户端不能伪造IP
+	if err := core.SetTrustedProxies(configService.GetStringSlice("app.trusted_proxies")); err != nil {
+		log.Fatalf("set trusted proxies error: %v", err)
+	}
+	// 客户端可以通过 X-Request-Timeout 缩短路由的超时时间
+	core.HonorTimeoutHeader = configService.GetBool("app.honor_timeout_header")
 	registerRouter(core)
 	server := &http.Server{
 		Handler: core,
-		Addr:    ":8080",
+		Addr:    configService.GetString("app.address"),
+	}
+
+	// 开始监听之前预热延迟实例化的服务，第一个请求不需要承担服务启动的耗时
+	warmupCtx, cancelWarmup := context.WithTimeout(context.Background(), 10*time.Second)
+	_, err = core.Warmup(warmupCtx, configService.GetDuration("app.warmup_timeout"))
+	cancelWarmup()
+	if err != nil {
+		log.Fatalf("warm up services error: %v", err)
 	}
 
 	go func() {
@@ -32,13 +103,50 @@ func main() {
 	}()
 
 	// 新建一个Signal类型的channel
-	quit := make(chan os.Signal)
+	quit := make(chan os.Signal, 1)
 	// 订阅这三种类型的关闭信号
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 	<-quit
 
-	if err := server.Shutdown(context.Background()); err != nil {
+	// 先让就绪检查失败，等待负载均衡摘除这个实例之后再停止接收新请求
+	framework.MustMakeAs[contract.Health](core.Container(), contract.HealthKey).SetShuttingDown()
+	time.Sleep(configService.GetDuration("app.shutdown_delay"))
+
+	// 先停止接收新请求并等待处理中的请求结束，再停止容器中的服务，整体最多等待5秒
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := server.Shutdown(ctx); err != nil {
 		log.Fatalf("shutdown server error: %v", err)
 	}
+	if err := core.Shutdown(ctx); err != nil {
+		log.Fatalf("shutdown services error: %v", err)
+	}
 
 }
+
+// openAccessLog 根据log.access配置项打开访问日志的输出目标，没有配置时返回nil
+func openAccessLog(core *gin.Engine, configService contract.Config) (io.WriteCloser, error) {
+	if !configService.IsExist("log.access") {
+		return nil, nil
+	}
+	var sink logprovider.SinkConfig
+	if err := configService.Load("log.access", &sink); err != nil {
+		return nil, err
+	}
+	appService := framework.MustMakeAs[contract.App](core.Container(), contract.AppKey)
+	writer, err := sink.Open(appService.LogFolder())
+	if err != nil {
+		return nil, err
+	}
+	if closer, ok := writer.(io.WriteCloser); ok && writer != os.Stdout {
+		return closer, nil
+	}
+	return nopCloser{writer}, nil
+}
+
+// nopCloser 控制台这样不需要关闭的输出目标
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
@@ -2,6 +2,7 @@ package demo
 
 import (
 	"fmt"
+	"reflect"
 
 	"github.com/RZXBxie/web_server/framework"
 )
@@ -13,6 +14,11 @@ func (sp *DemoServiceProvider) Name() string {
 	return Key
 }
 
+// Contract 声明服务实例需要实现Service接口
+func (sp *DemoServiceProvider) Contract() reflect.Type {
+	return framework.ContractOf[Service]()
+}
+
 // Register 方法是注册初始化服务实例的方法，我们这里先暂定为NewDemoService
 func (sp *DemoServiceProvider) Register(container framework.Container) framework.NewInstance {
 	return NewDemoService
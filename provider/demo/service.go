@@ -1,6 +1,7 @@
 package demo
 
 import (
+	"context"
 	"fmt"
 
 	"github.com/RZXBxie/web_server/framework"
@@ -25,3 +26,14 @@ func (s *DemoService) GetFoo() Foo {
 		Name: "i am foo",
 	}
 }
+
+// Stop 服务容器关闭时调用，这里只打印日志信息
+func (s *DemoService) Stop(ctx context.Context) error {
+	fmt.Println("demo service stop")
+	return nil
+}
+
+// HealthCheck demo服务没有外部依赖，总是健康的
+func (s *DemoService) HealthCheck(ctx context.Context) error {
+	return nil
+}
@@ -4,21 +4,35 @@ import (
 	"time"
 
 	"github.com/RZXBxie/web_server/controller"
+	"github.com/RZXBxie/web_server/framework"
+	"github.com/RZXBxie/web_server/framework/contract"
 	"github.com/RZXBxie/web_server/framework/gin"
 	"github.com/RZXBxie/web_server/framework/middleware"
+	"github.com/RZXBxie/web_server/framework/provider/health"
+	"github.com/RZXBxie/web_server/framework/provider/metrics"
 )
 
 func registerRouter(core *gin.Engine) {
-	// 静态路由匹配
-	duration := time.Second * 5
-	core.GET("/user/login", middleware.Timeout(duration), controller.UserLoginController)
-	// 路由组+动态路由匹配
-	subjectGroup := core.Group("/subject")
+	// debug模式下查看服务容器中绑定的服务
+	core.DebugContainer("/debug/container")
+	// 存活检查和就绪检查
+	health.RegisterRoutes(core)
+	// Prometheus格式的指标，路径在metrics配置中
+	metrics.RegisterRoutes(core)
+
+	// 静态路由匹配，超时时间从配置中读取，修改配置文件之后不需要重启
+	core.GET("/user/login", middleware.ConfigTimeout("app.timeout", 5*time.Second), controller.UserLoginController)
+	// 路由组+动态路由匹配，路由组中的路由都有超时时间，子路由组继承
+	subjectGroup := core.Group("/subject").WithTimeout(subjectTimeout(core))
+	// 按照客户端IP限流，策略在ratelimit配置中
+	subjectGroup.Use(middleware.RateLimit(middleware.RateLimitConfig{Name: "subject", Policy: rateLimitPolicy(core, "subject")}))
 	{
 		subjectGroup.DELETE("/:id", controller.SubjectDelController)
 		subjectGroup.GET("/:id", controller.SubjectGetController)
 		subjectGroup.PUT("/:id", controller.SubjectUpdateController)
-		subjectGroup.GET("/list/all", controller.SubjectListController)
+		subjectController := &controller.SubjectController{}
+		subjectGroup.MustInject(subjectController)
+		subjectGroup.GET("/list/all", subjectController.List)
 		subjectInnerGroup := subjectGroup.Group("/info")
 		{
 			subjectInnerGroup.Use(controller.UserLoginController)
@@ -26,3 +40,24 @@ func registerRouter(core *gin.Engine) {
 		}
 	}
 }
+
+// subjectTimeout 从配置中读取/subject下的路由的超时时间，没有配置时使用3秒
+func subjectTimeout(core *gin.Engine) time.Duration {
+	configService := framework.MustMakeAs[contract.Config](core.Container(), contract.ConfigKey)
+	if d := configService.GetDuration("app.subject_timeout"); d > 0 {
+		return d
+	}
+	return 3 * time.Second
+}
+
+// rateLimitPolicy 从配置中读取ratelimit.<name>的限流策略，没有配置时每个IP每分钟100个请求
+func rateLimitPolicy(core *gin.Engine, name string) contract.RateLimitPolicy {
+	configService := framework.MustMakeAs[contract.Config](core.Container(), contract.ConfigKey)
+	policy := contract.RateLimitPolicy{Algorithm: contract.SlidingWindow, Limit: 100, Window: time.Minute}
+	if configService.IsExist("ratelimit." + name) {
+		if err := configService.Load("ratelimit."+name, &policy); err != nil {
+			panic(err)
+		}
+	}
+	return policy
+}